@@ -0,0 +1,44 @@
+package addons
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInstallBinds = errors.New("failed to install bind config")
+
+const (
+	// BindsConfigName is the name of the generated config, it can be loaded with `exec bd_binds`.
+	BindsConfigName = "bd_binds"
+	// BindMarker prefixes the text echoed to the console by the generated aliases. It is used to tell our
+	// own control lines apart from any other console output.
+	BindMarker = "__bd_control__"
+)
+
+// InstallBinds writes a config into the tf2 cfg directory defining an alias for each action. Each alias is
+// named `bd_<action>` and echoes the BindMarker followed by the action name when run, which lets users bind
+// keys to bd actions without sending anything to the server.
+func InstallBinds(tf2dir string, actions []string) error {
+	var config strings.Builder
+
+	config.WriteString("// Generated by bd, any changes will be overwritten.\n")
+	config.WriteString("// Bind keys to these aliases in your autoexec.cfg, eg: bind F7 bd_kick_next\n")
+
+	for _, action := range actions {
+		fmt.Fprintf(&config, "alias bd_%s \"echo %s %s\"\n", action, BindMarker, action)
+	}
+
+	cfgDir := filepath.Join(tf2dir, "cfg")
+	if errMkdir := os.MkdirAll(cfgDir, 0o775); errMkdir != nil {
+		return errors.Join(errMkdir, ErrCreateOutput, ErrInstallBinds)
+	}
+
+	if errWrite := os.WriteFile(filepath.Join(cfgDir, BindsConfigName+".cfg"), []byte(config.String()), 0o600); errWrite != nil {
+		return errors.Join(errWrite, ErrWriteOutput, ErrInstallBinds)
+	}
+
+	return nil
+}
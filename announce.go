@@ -4,7 +4,6 @@
 	"context"
 	"fmt"
 	"log/slog"
-	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -13,10 +12,28 @@
 	"github.com/leighmacdonald/steamid/v4/steamid"
 )
 
-type kickRequest struct {
-	steamID steamid.SteamID
-	reason  KickReason
-}
+// Vote timings, these match the servers default sv_vote_creation_timer and sv_vote_failure_timer values.
+const (
+	voteCreationCooldown = time.Second * 150
+	voteFailureCooldown  = time.Second * 300
+	// voteRetryDelay is how long to wait before trying again when the callvote command itself failed.
+	voteRetryDelay = time.Second * 10
+)
+
+// callVoteResult is the outcome of trying to call a vote.
+//
+// The callvote command is forwarded to the server, which reports why a vote could not be started with a
+// CallVoteFailed user message that is never printed to the console. A vote that started and one that was
+// rejected for a cooldown or another vote in progress look the same to us, so the next vote is scheduled by
+// time alone.
+type callVoteResult string
+
+const (
+	// callVoteSent is used when the command was forwarded to the server, the vote may or may not have started.
+	callVoteSent callVoteResult = "sent"
+	// callVoteError is used when rcon failed or the console printed an error instead of forwarding the command.
+	callVoteError callVoteResult = "error"
+)
 
 // overwatch handles looking through the current player states and finding targets to attempt to perform action against.
 // This mainly includes announcing their status to lobby/in-game chat, and trying to kick them.
@@ -25,13 +42,24 @@ type kickRequest struct {
 // ui/api. These kicks are given first priority.
 type overwatch struct {
 	state    *gameState
-	rcon     rconConnection
+	rcon     rconExecutor
 	settings configManager
-	queued   []kickRequest
+	queue    *kickQueue
+	peers    *peerTracker
+	// nextVote is the earliest time we are allowed to call another vote.
+	nextVote time.Time
 }
 
-func newOverwatch(settings configManager, rcon rconConnection, state *gameState) overwatch {
-	return overwatch{settings: settings, rcon: rcon, state: state}
+func newOverwatch(settings configManager, rcon rconExecutor, state *gameState) overwatch {
+	queue := newKickQueue()
+
+	return overwatch{
+		settings: settings,
+		rcon:     rcon,
+		state:    state,
+		queue:    queue,
+		peers:    newPeerTracker(settings, state, queue),
+	}
 }
 
 func (bb *overwatch) start(ctx context.Context) {
@@ -39,48 +67,63 @@ func (bb *overwatch) start(ctx context.Context) {
 	for {
 		select {
 		case <-timer.C:
-			bb.update()
+			bb.update(ctx)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-// nextKickTarget searches for the next eligible target to initiate a vote kick against.
-func (bb *overwatch) nextKickTarget() (PlayerState, bool) {
-	var validTargets []PlayerState
+// isKickable checks if the player can be voted against by us. Votes can only be called against connected players
+// on our own team.
+func isKickable(player PlayerState, ourTeam Team, ourSteamID steamid.SteamID) bool {
+	return player.IsConnected && player.UserID > 0 && player.Team == ourTeam && player.SteamID != ourSteamID
+}
 
-	// Pull names from the manual queue first.
-	if len(bb.queued) > 0 {
-		player, errNotFound := bb.state.players.bySteamID(bb.queued[0].steamID)
-		if errNotFound != nil {
-			// They are not in the game anymore.
-			if len(bb.queued) > 1 {
-				bb.queued = slices.Delete(bb.queued, 0, 1)
-			} else {
-				bb.queued = bb.queued[1:]
-			}
+// nextKickTarget searches for the next eligible target to initiate a vote kick against. Players in the manual
+// queue are returned first, queued players that have left the game are dropped from the queue. Automatic targets
+// are only returned when auto is true.
+func (bb *overwatch) nextKickTarget(settings userSettings, ourTeam Team, auto bool) (kickRequest, bool) {
+	ourSteamID := settings.GetSteamID()
+
+	bb.queue.prune(func(steamID steamid.SteamID) bool {
+		player, errNotFound := bb.state.players.bySteamID(steamID)
+
+		return errNotFound == nil && player.IsConnected
+	})
+
+	now := time.Now()
+
+	for _, request := range bb.queue.entries() {
+		player, errNotFound := bb.state.players.bySteamID(request.steamID)
+		if errNotFound == nil && isKickable(player, ourTeam, ourSteamID) && !bb.peers.claimed(player.UserID, now) {
+			return request, true
 		}
+	}
 
-		validTargets = append(validTargets, player)
+	if !auto || len(settings.KickTags) == 0 {
+		return kickRequest{}, false
 	}
 
+	var validTargets []PlayerState
+
 	for _, player := range bb.state.players.current() {
-		if len(player.Matches) > 0 && !player.Whitelist {
+		if isKickable(player, ourTeam, ourSteamID) && !player.Whitelist && player.MatchAttr(settings.KickTags) &&
+			!bb.peers.claimed(player.UserID, now) {
 			validTargets = append(validTargets, player)
 		}
 	}
 
 	if len(validTargets) == 0 {
-		return PlayerState{}, false
+		return kickRequest{}, false
 	}
 
 	// Find players we have not tried yet.
-	sort.Slice(validTargets, func(i, j int) bool {
+	sort.SliceStable(validTargets, func(i, j int) bool {
 		return validTargets[i].KickAttemptCount < validTargets[j].KickAttemptCount
 	})
 
-	return validTargets[0], true
+	return kickRequest{steamID: validTargets[0].SteamID, reason: KickReasonCheating}, true
 }
 
 // announceMatch handles announcing after a match is triggered against a player.
@@ -119,10 +162,40 @@ func (bb *overwatch) announceMatch(ctx context.Context, player PlayerState, matc
 	}
 
 	if settings.ChatWarningsEnabled && time.Since(player.AnnouncedPartyLast) >= DurationAnnounceMatchTimeout {
+		if us, errUs := bb.state.players.bySteamID(settings.GetSteamID()); errUs == nil &&
+			!bb.peers.isAnnouncer(us.SteamID, us.Team, time.Now()) {
+			// Another bd instance on our team is handling announcements.
+			player.AnnouncedPartyLast = time.Now()
+
+			bb.state.players.update(player)
+
+			return
+		}
+
+		announcements, errAnnouncements := bb.announcements(ctx)
+		if errAnnouncements != nil {
+			slog.Error("Failed to load announcements", errAttr(errAnnouncements))
+
+			return
+		}
+
 		// Don't spam friends, but eventually remind them if they manage to forget long enough
 		for _, match := range matches {
-			if errLog := bb.sendChat(ctx, ChatDestParty, "(%d) [%s] [%s] %s ", player.UserID, match.Origin, strings.Join(match.Attributes, ","), player.Personaname); errLog != nil {
-				slog.Error("Failed to send party log message", errAttr(errLog))
+			announcement, enabled := selectAnnouncement(announcements, match)
+			if !enabled {
+				continue
+			}
+
+			msg, errRender := renderAnnouncement(announcement.Template, newAnnounceData(player, match))
+			if errRender != nil {
+				slog.Error("Failed to render announcement", slog.String("severity", announcement.Severity),
+					errAttr(errRender))
+
+				continue
+			}
+
+			if errLog := bb.sendChat(ctx, announcement.Destination, "%s %s", msg, peerTag); errLog != nil {
+				slog.Error("Failed to send announcement", errAttr(errLog))
 
 				return
 			}
@@ -134,19 +207,49 @@ func (bb *overwatch) announceMatch(ctx context.Context, player PlayerState, matc
 	}
 }
 
-// sendChat is used to send chat messages to the various chat interfaces in game: say|say_team|say_party.
-func (bb *overwatch) sendChat(ctx context.Context, destination ChatDest, format string, args ...any) error {
-	var cmd string
+// announceMatches announces any connected, matched players whose previous announcement has expired.
+func (bb *overwatch) announceMatches(ctx context.Context) {
+	for _, player := range bb.state.players.current() {
+		if !player.IsConnected || len(player.Matches) == 0 {
+			continue
+		}
+
+		if time.Since(player.AnnouncedGeneralLast) < DurationAnnounceMatchTimeout &&
+			time.Since(player.AnnouncedPartyLast) < DurationAnnounceMatchTimeout {
+			continue
+		}
+
+		bb.announceMatch(ctx, player, player.Matches)
+	}
+}
+
+// sayCommand formats the command used to send the message to the chat destination: say|say_team|say_party.
+// Messages often include player names, so anything that would let them run other commands is replaced.
+func sayCommand(destination ChatDest, msg string) (string, error) {
+	msg = strings.TrimSpace(chatUnsafe.Replace(msg))
 
 	switch destination {
 	case ChatDestAll:
-		cmd = fmt.Sprintf("say %s", fmt.Sprintf(format, args...))
+		return fmt.Sprintf("say %s", msg), nil
 	case ChatDestTeam:
-		cmd = fmt.Sprintf("say_team %s", fmt.Sprintf(format, args...))
+		return fmt.Sprintf("say_team %s", msg), nil
 	case ChatDestParty:
-		cmd = fmt.Sprintf("say_party %s", fmt.Sprintf(format, args...))
+		return fmt.Sprintf("say_party %s", msg), nil
 	default:
-		return fmt.Errorf("%w: %s", errInvalidChatType, destination)
+		return "", fmt.Errorf("%w: %s", errInvalidChatType, destination)
+	}
+}
+
+// sendChat is used to send chat messages to the various chat interfaces in game. When the messages are queued
+// by the scheduler they are not waited on, so chat never holds up kicks waiting on the chat rate limits.
+func (bb *overwatch) sendChat(ctx context.Context, destination ChatDest, format string, args ...any) error {
+	cmd, errCmd := sayCommand(destination, fmt.Sprintf(format, args...))
+	if errCmd != nil {
+		return errCmd
+	}
+
+	if poster, ok := bb.rcon.(rconPoster); ok {
+		return poster.post(ctx, cmd)
 	}
 
 	resp, errExec := bb.rcon.exec(ctx, cmd, false)
@@ -159,21 +262,96 @@ func (bb *overwatch) sendChat(ctx context.Context, destination ChatDest, format
 	return nil
 }
 
-func (bb *overwatch) update() {
-}
+// update announces any matched players, then calls a vote against the next kick target when we are able to. The
+// manual queue is always processed, automatic targets are only picked when the kicker is enabled.
+func (bb *overwatch) update(ctx context.Context) {
+	bb.announceMatches(ctx)
 
-func (bb *overwatch) kick(ctx context.Context, player PlayerState, reason KickReason) {
-	player.KickAttemptCount++
+	if time.Now().Before(bb.nextVote) {
+		return
+	}
+
+	settings, errSettings := bb.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to load settings", errAttr(errSettings))
+
+		return
+	}
+
+	if bb.queue.len() == 0 && !settings.KickerEnabled {
+		return
+	}
+
+	us, errUs := bb.state.players.bySteamID(settings.GetSteamID())
+	if errUs != nil || (us.Team != Red && us.Team != Blu) {
+		// Votes can only be called once we have joined a team.
+		return
+	}
+
+	request, found := bb.nextKickTarget(settings, us.Team, settings.KickerEnabled)
+	if !found {
+		return
+	}
+
+	player, errPlayer := bb.state.players.bySteamID(request.steamID)
+	if errPlayer != nil {
+		return
+	}
 
-	defer bb.state.players.update(player)
+	bb.kick(ctx, us.SteamID, player, request.reason)
+}
 
+// kick calls a vote to kick the player and schedules when the next vote can be called, depending on the outcome.
+// The outcome is recorded against the players manual queue entry, if they have one.
+func (bb *overwatch) kick(ctx context.Context, caller steamid.SteamID, player PlayerState, reason KickReason) {
 	cmd := fmt.Sprintf("callvote kick \"%d %s\"", player.UserID, reason)
 
 	resp, errCallVote := bb.rcon.exec(ctx, cmd, false)
 	if errCallVote != nil {
 		slog.Error("Failed to call vote", slog.String("steam_id", player.SteamID.String()), errAttr(errCallVote))
 
+		bb.nextVote = time.Now().Add(voteRetryDelay)
+		bb.queue.recordAttempt(player.SteamID, callVoteError)
+
 		return
 	}
-	slog.Debug("Kick response", slog.String("resp", resp))
+
+	// Any output means the client refused to send the command, eg: when not connected to a server.
+	result, wait := callVoteSent, voteCreationCooldown
+	if strings.TrimSpace(resp) != "" {
+		result, wait = callVoteError, voteRetryDelay
+	}
+
+	bb.nextVote = time.Now().Add(wait)
+	bb.queue.recordAttempt(player.SteamID, result)
+
+	if result == callVoteSent {
+		bb.state.recordKickVote(ctx, caller, player, reason, kickVotePending, "")
+
+		// Claims are only sent once we know we are not alone, to avoid the extra chat when playing by ourselves.
+		if bb.peers.active(time.Now()) {
+			if errClaim := bb.sendChat(ctx, ChatDestTeam, "%s", peerKickClaim(player.UserID)); errClaim != nil {
+				slog.Error("Failed to send kick claim", errAttr(errClaim))
+			}
+		}
+	} else {
+		bb.state.recordKickVote(ctx, caller, player, reason, kickVoteRejected, strings.TrimSpace(resp))
+	}
+
+	slog.Debug("Kick response", slog.String("resp", resp), slog.String("result", string(result)),
+		slog.Duration("wait", wait))
+
+	// The state may have been updated while waiting on the response.
+	if current, errCurrent := bb.state.players.bySteamID(player.SteamID); errCurrent == nil {
+		player = current
+	}
+
+	// Move on to other targets before retrying this one.
+	player.KickAttemptCount++
+
+	if result == callVoteSent {
+		player.VotedAgainstLast = time.Now()
+	}
+
+	bb.state.players.update(player)
 }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestOverwatch creates an overwatch with chat warnings disabled, so only kick votes are sent.
+func newTestOverwatch(t *testing.T, kickerEnabled bool) (*overwatch, *mockRcon) {
+	t.Helper()
+
+	state, rcon := newTestState(t, map[string]any{
+		"kicker_enabled": kickerEnabled, "kick_tags": "cheater,bot", "chat_warnings_enabled": false,
+	})
+	watch := newOverwatch(state.settings, rcon, state)
+
+	state.players.update(PlayerState{SteamID: testUs, UserID: 1, Team: Red, IsConnected: true})
+
+	return &watch, rcon
+}
+
+func testKickTarget(sid int64, userID int, team Team, attrs ...string) PlayerState {
+	player := PlayerState{SteamID: steamid.New(sid), UserID: userID, Team: team, IsConnected: true}
+	if len(attrs) > 0 {
+		player.Matches = []rules.MatchResult{{Origin: "test", Attributes: attrs, MatcherType: "steam"}}
+	}
+
+	return player
+}
+
+func TestOverwatchKick(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, true)
+
+	// Other team, untagged, ignored tag and whitelisted players are never targeted.
+	watch.state.players.update(testKickTarget(76561198084134025, 2, Blu, "cheater"))
+	watch.state.players.update(testKickTarget(76561197970669109, 3, Red))
+	watch.state.players.update(testKickTarget(76561197992870439, 4, Red, "racist"))
+
+	whitelisted := testKickTarget(76561198057999536, 5, Red, "cheater")
+	whitelisted.Whitelist = true
+	watch.state.players.update(whitelisted)
+
+	watch.update(ctx)
+	require.Empty(t, rcon.commands)
+
+	watch.state.players.update(testKickTarget(76561197960265729, 6, Red, "bot"))
+	watch.state.players.update(testKickTarget(76561197960265730, 7, Red, "cheater"))
+
+	watch.update(ctx)
+	require.Equal(t, []string{`callvote kick "6 cheating"`}, rcon.commands)
+
+	// Waiting for the vote cooldown.
+	watch.update(ctx)
+	require.Len(t, rcon.commands, 1)
+
+	// Cycles to the target we have not tried yet.
+	watch.nextVote = time.Time{}
+	watch.update(ctx)
+	require.Equal(t, `callvote kick "7 cheating"`, rcon.commands[1])
+
+	first, errFirst := watch.state.players.bySteamID(steamid.New(76561197960265729))
+	require.NoError(t, errFirst)
+	require.Equal(t, 1, first.KickAttemptCount)
+	require.False(t, first.VotedAgainstLast.IsZero())
+}
+
+func TestOverwatchKickQueue(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, false)
+
+	queued := testKickTarget(76561198084134025, 2, Red)
+	watch.state.players.update(queued)
+	watch.state.players.update(testKickTarget(76561197970669109, 3, Red, "cheater"))
+
+	watch.queue.enqueue(steamid.New(76561197992870439), KickReasonIdle, -1) // Not in the game
+	watch.queue.enqueue(queued.SteamID, KickReasonScamming, -1)
+
+	// The client refused to send the command, so the same target is tried again shortly.
+	rcon.responses = []string{"Unknown command: callvote"}
+
+	watch.update(ctx)
+	require.Equal(t, []string{`callvote kick "2 scamming"`}, rcon.commands)
+	require.WithinDuration(t, time.Now().Add(voteRetryDelay), watch.nextVote, time.Second)
+
+	entries := watch.queue.entries()
+	require.Len(t, entries, 1, "Players no longer in the game are dropped")
+	require.Equal(t, 1, entries[0].attempts)
+	require.Equal(t, callVoteError, entries[0].outcome)
+
+	// Whether the vote started is never reported, so sent votes always wait out the creation cooldown.
+	watch.nextVote = time.Time{}
+	watch.update(ctx)
+	require.Equal(t, []string{`callvote kick "2 scamming"`, `callvote kick "2 scamming"`}, rcon.commands)
+	require.Equal(t, callVoteSent, watch.queue.entries()[0].outcome)
+	require.WithinDuration(t, time.Now().Add(voteCreationCooldown), watch.nextVote, time.Second)
+
+	// Queued players are kept until they leave, so the kicker is only idle once they are gone.
+	watch.state.players.update(testKickTarget(76561197960265729, 8, Red, "cheater"))
+	queued.IsConnected = false
+	watch.state.players.update(queued)
+
+	watch.nextVote = time.Time{}
+	watch.update(ctx)
+	require.Len(t, rcon.commands, 2, "Kicker is disabled so nothing is picked automatically")
+	require.Zero(t, watch.queue.len())
+}
+
+func TestKickQueueOrder(t *testing.T) {
+	var (
+		queue  = newKickQueue()
+		first  = steamid.New(76561198084134025)
+		second = steamid.New(76561197970669109)
+		third  = steamid.New(76561197992870439)
+	)
+
+	queue.enqueue(first, KickReasonCheating, -1)
+	queue.enqueue(second, KickReasonCheating, -1)
+	queue.enqueue(third, KickReasonIdle, 0)
+
+	order := func() steamid.Collection {
+		var sids steamid.Collection
+		for _, request := range queue.entries() {
+			sids = append(sids, request.steamID)
+		}
+
+		return sids
+	}
+
+	require.Equal(t, steamid.Collection{third, first, second}, order())
+
+	// Moving keeps the attempts made so far.
+	queue.recordAttempt(second, callVoteError)
+	queue.enqueue(second, KickReasonScamming, 1)
+	require.Equal(t, steamid.Collection{third, second, first}, order())
+	require.Equal(t, 1, queue.entries()[1].attempts)
+	require.Equal(t, KickReasonScamming, queue.entries()[1].reason)
+
+	// Sent votes rotate to the back.
+	queue.recordAttempt(third, callVoteSent)
+	require.Equal(t, steamid.Collection{second, first, third}, order())
+
+	require.True(t, queue.remove(first))
+	require.False(t, queue.remove(first))
+	require.Equal(t, steamid.Collection{second, third}, order())
+}
+
+func TestKickVoteOutcomes(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, true)
+
+	var (
+		first  = testKickTarget(76561197960265729, 6, Red, "cheater")
+		second = testKickTarget(76561197960265730, 7, Red, "cheater", "bot")
+		db     = watch.state.db
+	)
+
+	watch.state.players.update(first)
+	watch.state.players.update(second)
+	watch.state.updateConnected(ctx, steamid.Collection{testUs, first.SteamID, second.SteamID})
+
+	rcon.responses = []string{"", "", "Unknown command: callvote"}
+
+	// The target leaves while the vote is active.
+	watch.update(ctx)
+	watch.state.updateConnected(ctx, steamid.Collection{testUs, second.SteamID})
+
+	// Nobody leaves before the vote times out.
+	watch.nextVote = time.Time{}
+	watch.update(ctx)
+	watch.state.expireKickVote(ctx, time.Now().Add(kickVoteTimeout*2))
+
+	watch.nextVote = time.Time{}
+	watch.update(ctx)
+
+	votes, errVotes := db.KickVotes(ctx, 0)
+	require.NoError(t, errVotes)
+	require.Len(t, votes, 3)
+
+	outcomes := map[string]int{}
+	for _, vote := range votes {
+		outcomes[vote.Outcome]++
+
+		require.True(t, vote.Ours)
+		require.True(t, vote.EndTime.Valid)
+	}
+
+	require.Equal(t, map[string]int{kickVoteTargetLeft: 1, kickVoteTargetStayed: 1, kickVoteRejected: 1}, outcomes)
+
+	stats, errStats := db.KickVoteStatsByTag(ctx)
+	require.NoError(t, errStats)
+
+	for _, stat := range stats {
+		switch stat.Value {
+		case "cheater":
+			require.Equal(t, int64(3), stat.Total)
+			require.Equal(t, int64(1), stat.TargetLeft)
+		case "bot":
+			require.Equal(t, int64(2), stat.Total)
+			require.Equal(t, int64(0), stat.TargetLeft)
+		case "test":
+			require.Equal(t, kickTagList, stat.Kind)
+		}
+	}
+}
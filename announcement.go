@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/bd/store"
+)
+
+// Announcement severities, in the order they are checked.
+const (
+	severityHigh   = "high"
+	severityMedium = "medium"
+	severityLow    = "low"
+)
+
+// Announcement is the chat template used to announce matches of a given severity. Attributes lists the match
+// attributes that belong to the severity, when empty every match does.
+type Announcement struct {
+	Severity    string   `json:"severity"`
+	Attributes  []string `json:"attributes"`
+	Destination ChatDest `json:"destination"`
+	Template    string   `json:"template"`
+	Enabled     bool     `json:"enabled"`
+}
+
+func newAnnouncement(row store.Announcement) Announcement {
+	announcement := Announcement{
+		Severity:    row.Severity,
+		Attributes:  []string{},
+		Destination: ChatDest(row.Destination),
+		Template:    row.Template,
+		Enabled:     row.Enabled,
+	}
+
+	if row.Attributes != "" {
+		announcement.Attributes = strings.Split(row.Attributes, ",")
+	}
+
+	return announcement
+}
+
+// AnnounceData holds the fields available to announcement templates.
+type AnnounceData struct {
+	Name       string
+	UserID     int
+	Team       Team
+	Attributes []string
+	Origin     string
+	Score      int
+	TimesSeen  int64
+}
+
+func newAnnounceData(player PlayerState, match rules.MatchResult) AnnounceData {
+	return AnnounceData{
+		Name:       player.Personaname,
+		UserID:     player.UserID,
+		Team:       player.Team,
+		Attributes: match.Attributes,
+		Origin:     match.Origin,
+		Score:      player.Score,
+		TimesSeen:  player.TimesSeen,
+	}
+}
+
+// chatUnsafe holds the characters that would let a player name break out of the chat command it is sent with.
+var chatUnsafe = strings.NewReplacer(`"`, "'", ";", ",", "\n", " ", "\r", " ")
+
+func parseAnnouncement(text string) (*template.Template, error) {
+	tmpl, errParse := template.New("announcement").
+		Funcs(template.FuncMap{"join": strings.Join, "upper": strings.ToUpper}).
+		Parse(text)
+	if errParse != nil {
+		return nil, errors.Join(errParse, errAnnouncementTemplate)
+	}
+
+	return tmpl, nil
+}
+
+// renderAnnouncement renders the template with the data, making the result safe to send as a chat message.
+func renderAnnouncement(text string, data AnnounceData) (string, error) {
+	tmpl, errParse := parseAnnouncement(text)
+	if errParse != nil {
+		return "", errParse
+	}
+
+	var buf bytes.Buffer
+	if errExec := tmpl.Execute(&buf, data); errExec != nil {
+		return "", errors.Join(errExec, errAnnouncementTemplate)
+	}
+
+	return strings.TrimSpace(chatUnsafe.Replace(buf.String())), nil
+}
+
+// selectAnnouncement finds the announcement to use for the match. Announcements must be ordered by severity. False
+// is returned when the matching severity is disabled.
+func selectAnnouncement(announcements []Announcement, match rules.MatchResult) (Announcement, bool) {
+	for _, announcement := range announcements {
+		if len(announcement.Attributes) > 0 && !slices.ContainsFunc(announcement.Attributes, match.HasAttr) {
+			continue
+		}
+
+		return announcement, announcement.Enabled
+	}
+
+	return Announcement{}, false
+}
+
+func (bb *overwatch) announcements(ctx context.Context) ([]Announcement, error) {
+	rows, errRows := bb.state.db.Announcements(ctx)
+	if errRows != nil {
+		return nil, errors.Join(errRows, errQueryAnnouncements)
+	}
+
+	announcements := make([]Announcement, len(rows))
+	for index, row := range rows {
+		announcements[index] = newAnnouncement(row)
+	}
+
+	return announcements, nil
+}
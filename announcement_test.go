@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/bd/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderAnnouncement(t *testing.T) {
+	var (
+		player = PlayerState{Personaname: `evil"; quit`, UserID: 12, Team: Blu, Score: 5, TimesSeen: 3}
+		match  = rules.MatchResult{Origin: "bots", Attributes: []string{"cheater", "bot"}}
+	)
+
+	msg, errRender := renderAnnouncement(`{{.Team}} #{{.UserID}} {{.Name}} [{{join .Attributes "/"}}] `+
+		`{{.Origin}} {{.Score}} {{.TimesSeen}}`, newAnnounceData(player, match))
+	require.NoError(t, errRender)
+	require.Equal(t, "blu #12 evil', quit [cheater/bot] bots 5 3", msg)
+
+	_, errParse := renderAnnouncement("{{.Name", newAnnounceData(player, match))
+	require.ErrorIs(t, errParse, errAnnouncementTemplate)
+
+	_, errExec := renderAnnouncement("{{.Missing}}", newAnnounceData(player, match))
+	require.ErrorIs(t, errExec, errAnnouncementTemplate)
+}
+
+func TestSelectAnnouncement(t *testing.T) {
+	announcements := []Announcement{
+		{Severity: severityHigh, Attributes: []string{"cheater"}, Enabled: true},
+		{Severity: severityMedium, Attributes: []string{"racist"}},
+		{Severity: severityLow, Attributes: []string{}, Enabled: true},
+	}
+
+	announcement, enabled := selectAnnouncement(announcements, rules.MatchResult{Attributes: []string{"bot", "cheater"}})
+	require.True(t, enabled)
+	require.Equal(t, severityHigh, announcement.Severity)
+
+	_, enabled = selectAnnouncement(announcements, rules.MatchResult{Attributes: []string{"racist"}})
+	require.False(t, enabled, "Disabled severities are not announced")
+
+	announcement, enabled = selectAnnouncement(announcements, rules.MatchResult{Attributes: []string{"other"}})
+	require.True(t, enabled)
+	require.Equal(t, severityLow, announcement.Severity)
+}
+
+func TestAnnounceMatches(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, false)
+
+	player := testKickTarget(76561197960265729, 6, Blu, "cheater")
+	player.Personaname = "bot"
+	watch.state.players.update(player)
+
+	watch.announceMatches(ctx)
+	require.Empty(t, rcon.commands, "Chat warnings are disabled")
+
+	settings, errSettings := watch.settings.settings(ctx)
+	require.NoError(t, errSettings)
+
+	settings.ChatWarningsEnabled = true
+	require.NoError(t, watch.settings.save(ctx, settings))
+
+	require.NoError(t, watch.state.db.AnnouncementUpdate(ctx, store.AnnouncementUpdateParams{
+		Attributes:  "cheater",
+		Destination: string(ChatDestTeam),
+		Template:    `{{.Name}} is a {{join .Attributes ","}}`,
+		Enabled:     true,
+		Severity:    severityHigh,
+	}))
+
+	watch.announceMatches(ctx)
+	require.Equal(t, []string{"say_team bot is a cheater [bd]"}, rcon.commands)
+
+	// Not repeated until the timeout has passed.
+	watch.announceMatches(ctx)
+	require.Len(t, rcon.commands, 1)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/leighmacdonald/bd/addons"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// bindAction is an action that can be triggered from a console bind. Each action gets a `bd_<action>` alias in
+// the generated bind config.
+type bindAction string
+
+const (
+	bindKickNext       bindAction = "kick_next"
+	bindMarkLastKiller bindAction = "mark_last_killer"
+	bindWhitelistLast  bindAction = "wl_last_killer"
+	bindStatus         bindAction = "status"
+)
+
+// bindActions are all the actions written to the bind config.
+func bindActions() []string {
+	return []string{string(bindKickNext), string(bindMarkLastKiller), string(bindWhitelistLast), string(bindStatus)}
+}
+
+// installBinds writes the bind config into the games cfg directory.
+func installBinds(tf2Dir string) error {
+	return addons.InstallBinds(tf2Dir, bindActions())
+}
+
+// onControl handles the control lines echoed to the console by the bind aliases. Unlike the chat commands, the
+// result is echoed back to our own console so nothing is visible to other players.
+func (c chatCommander) onControl(ctx context.Context, evt LogEvent) {
+	settings, errSettings := c.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to load settings", errAttr(errSettings))
+
+		return
+	}
+
+	var (
+		result  string
+		errExec error
+	)
+
+	switch bindAction(evt.MetaData) {
+	case bindKickNext:
+		result, errExec = c.kickNext(settings)
+	case bindMarkLastKiller:
+		result, errExec = c.applyLastKiller(ctx, settings, chatCommandMark, []string{"cheater"})
+	case bindWhitelistLast:
+		result, errExec = c.applyLastKiller(ctx, settings, chatCommandWhitelist, nil)
+	case bindStatus:
+		result = c.status()
+	default:
+		errExec = fmt.Errorf("%w: %s", errChatCommandUnknown, evt.MetaData)
+	}
+
+	if errExec != nil {
+		slog.Error("Failed to execute bind action", slog.String("action", evt.MetaData), errAttr(errExec))
+
+		result = errExec.Error()
+	}
+
+	// Results include player names, which must not be able to close the quotes and run other commands.
+	echo := fmt.Sprintf("echo \"[bd] %s\"", strings.TrimSpace(chatUnsafe.Replace(result)))
+	if _, errEcho := c.overwatch.rcon.exec(ctx, echo, false); errEcho != nil {
+		slog.Error("Failed to echo bind result", errAttr(errEcho))
+	}
+}
+
+// kickNext moves the next kick target to the front of the kick queue, automatic targets are considered even when
+// the kicker is disabled.
+func (c chatCommander) kickNext(settings userSettings) (string, error) {
+	us, errUs := c.state.players.bySteamID(settings.GetSteamID())
+	if errUs != nil || (us.Team != Red && us.Team != Blu) {
+		return "", errNoKickTarget
+	}
+
+	request, found := c.overwatch.nextKickTarget(settings, us.Team, true)
+	if !found {
+		return "", errNoKickTarget
+	}
+
+	player, errPlayer := c.state.players.bySteamID(request.steamID)
+	if errPlayer != nil {
+		return "", errNoKickTarget
+	}
+
+	c.overwatch.queue.enqueue(request.steamID, request.reason, 0)
+
+	return fmt.Sprintf("queued kick of %s (%s)", player.Personaname, request.reason), nil
+}
+
+// applyLastKiller performs the command against the last player to kill us in the current session.
+func (c chatCommander) applyLastKiller(ctx context.Context, settings userSettings, name chatCommandName,
+	args []string,
+) (string, error) {
+	killer, found := lastKiller(c.state.KillFeed(), settings.GetSteamID())
+	if !found {
+		return "", errNoLastKiller
+	}
+
+	player, errPlayer := c.state.players.bySteamID(killer)
+	if errPlayer != nil {
+		return "", fmt.Errorf("%w: %s", errPlayerNotFound, killer.String())
+	}
+
+	return c.apply(ctx, settings, name, player, args)
+}
+
+// lastKiller returns the most recent player in the kill feed to have killed us, ignoring suicides.
+func lastKiller(feed []KillFeedEntry, ourSteamID steamid.SteamID) (steamid.SteamID, bool) {
+	for index := len(feed) - 1; index >= 0; index-- {
+		entry := feed[index]
+		if entry.VictimSID == ourSteamID && entry.KillerSID.Valid() && entry.KillerSID != ourSteamID {
+			return entry.KillerSID, true
+		}
+	}
+
+	return steamid.SteamID{}, false
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastKiller(t *testing.T) {
+	var (
+		us     = steamid.New(76561197961279983)
+		first  = steamid.New(76561198084134025)
+		second = steamid.New(76561197970669109)
+	)
+
+	_, found := lastKiller(nil, us)
+	require.False(t, found)
+
+	feed := []KillFeedEntry{
+		{KillerSID: first, VictimSID: us},
+		{KillerSID: second, VictimSID: us},
+		{KillerSID: us, VictimSID: first},
+		// Suicide
+		{KillerSID: us, VictimSID: us},
+	}
+
+	killer, found := lastKiller(feed, us)
+	require.True(t, found)
+	require.Equal(t, second, killer)
+}
+
+func TestControlHostileName(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, false)
+	commander := chatCommander{settings: watch.settings, state: watch.state, overwatch: watch}
+
+	hostile := testKickTarget(76561197970669109, 5, Red, "cheater")
+	hostile.Personaname = "bot\"; quit; echo \"gg"
+	watch.state.players.update(hostile)
+
+	commander.onControl(ctx, LogEvent{Type: EvtControl, MetaData: string(bindKickNext)})
+	require.Equal(t, []string{"echo \"[bd] queued kick of bot', quit, echo 'gg (cheating)\""}, rcon.commands)
+}
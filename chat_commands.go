@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/bd/store"
+)
+
+// chatCommandPrefix marks our own chat messages which should be treated as commands.
+const chatCommandPrefix = "!bd"
+
+type chatCommandName string
+
+const (
+	chatCommandMark      chatCommandName = "mark"
+	chatCommandKick      chatCommandName = "kick"
+	chatCommandWhitelist chatCommandName = "wl"
+	chatCommandStatus    chatCommandName = "status"
+)
+
+type chatCommand struct {
+	name   chatCommandName
+	userID int
+	args   []string
+}
+
+// parseChatCommand parses a chat message in the form of `!bd <command> [userid] [args...]`. Messages that
+// do not start with the command prefix return false.
+func parseChatCommand(message string) (chatCommand, bool, error) {
+	fields := strings.Fields(message)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], chatCommandPrefix) {
+		return chatCommand{}, false, nil
+	}
+
+	if len(fields) < 2 {
+		return chatCommand{}, true, errChatCommandUnknown
+	}
+
+	command := chatCommand{name: chatCommandName(strings.ToLower(fields[1]))}
+
+	switch command.name {
+	case chatCommandStatus:
+		return command, true, nil
+	case chatCommandMark, chatCommandKick, chatCommandWhitelist:
+	default:
+		return chatCommand{}, true, fmt.Errorf("%w: %s", errChatCommandUnknown, fields[1])
+	}
+
+	if len(fields) < 3 {
+		return chatCommand{}, true, errChatCommandArgs
+	}
+
+	userID, errUserID := strconv.Atoi(strings.TrimPrefix(fields[2], "#"))
+	if errUserID != nil || userID <= 0 {
+		return chatCommand{}, true, fmt.Errorf("%w: %s", errChatCommandArgs, fields[2])
+	}
+
+	command.userID = userID
+	command.args = fields[3:]
+
+	if command.name == chatCommandMark && len(command.args) == 0 {
+		return chatCommand{}, true, errChatCommandArgs
+	}
+
+	return command, true, nil
+}
+
+// chatCommander lets the user control bd from in-game chat, so they do not need to leave the game. Only messages
+// sent by the configured steam id are considered, replies are sent to party chat. Actions triggered by the
+// console binds are also handled here, see onControl.
+type chatCommander struct {
+	incoming  chan LogEvent
+	settings  configManager
+	db        store.Querier
+	state     *gameState
+	re        *rules.Engine
+	overwatch *overwatch
+}
+
+func newChatCommander(settings configManager, db store.Querier, state *gameState, re *rules.Engine,
+	overwatch *overwatch, broadcaster *eventBroadcaster,
+) chatCommander {
+	commander := chatCommander{
+		incoming:  make(chan LogEvent, eventQueueSize),
+		settings:  settings,
+		db:        db,
+		state:     state,
+		re:        re,
+		overwatch: overwatch,
+	}
+
+	broadcaster.registerConsumer("chat_commands", commander.incoming, policyDropOldest, EvtMsg, EvtControl)
+
+	return commander
+}
+
+func (c chatCommander) start(ctx context.Context) {
+	for {
+		select {
+		case evt := <-c.incoming:
+			if evt.Replayed {
+				continue
+			}
+
+			if evt.Type == EvtControl {
+				c.onControl(ctx, evt)
+			} else {
+				c.onMessage(ctx, evt)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c chatCommander) onMessage(ctx context.Context, evt LogEvent) {
+	command, isCommand, errParse := parseChatCommand(evt.Message)
+	if !isCommand {
+		return
+	}
+
+	settings, errSettings := c.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to load settings", errAttr(errSettings))
+
+		return
+	}
+
+	if !c.sentByUs(settings, evt) {
+		slog.Warn("Ignoring chat command not sent by us", slog.String("name", evt.Player))
+
+		return
+	}
+
+	var reply string
+
+	if errParse != nil {
+		reply = errParse.Error()
+	} else {
+		result, errExec := c.exec(ctx, settings, command)
+		if errExec != nil {
+			slog.Error("Failed to execute chat command", slog.String("command", string(command.name)), errAttr(errExec))
+
+			reply = errExec.Error()
+		} else {
+			reply = result
+		}
+	}
+
+	if errChat := c.overwatch.sendChat(ctx, ChatDestParty, "[bd] %s", reply); errChat != nil {
+		slog.Error("Failed to send chat command reply", errAttr(errChat))
+	}
+}
+
+// sentByUs checks that the chat line really came from us. Chat lines only carry the name of the sender, so
+// the line is rejected when anyone else connected has the same name, or when the dead and spectator flags
+// of the line do not agree with our own state.
+func (c chatCommander) sentByUs(settings userSettings, evt LogEvent) bool {
+	sender, errSender := c.state.players.byUniqueName(evt.Player)
+	if errSender != nil || sender.SteamID != settings.GetSteamID() {
+		return false
+	}
+
+	if evt.Dead == sender.Alive {
+		return false
+	}
+
+	return evt.Spectator == (sender.Team == Spec) && !evt.Coach
+}
+
+func (c chatCommander) exec(ctx context.Context, settings userSettings, command chatCommand) (string, error) {
+	if command.name == chatCommandStatus {
+		return c.status(), nil
+	}
+
+	player, errPlayer := c.state.players.byUserID(command.userID)
+	if errPlayer != nil {
+		return "", fmt.Errorf("%w: #%d", errPlayerNotFound, command.userID)
+	}
+
+	return c.apply(ctx, settings, command.name, player, command.args)
+}
+
+// status summarises the current game for the user.
+func (c chatCommander) status() string {
+	var matched int
+
+	players := c.state.players.current()
+	for _, player := range players {
+		if len(player.Matches) > 0 {
+			matched++
+		}
+	}
+
+	return fmt.Sprintf("players: %d matched: %d kick queue: %d", len(players), matched, c.overwatch.queue.len())
+}
+
+// apply performs the command against the player.
+func (c chatCommander) apply(ctx context.Context, settings userSettings, name chatCommandName, player PlayerState,
+	args []string,
+) (string, error) {
+	switch name {
+	case chatCommandMark:
+		if errMark := mark(ctx, settings, c.db, c.state, c.re, player.SteamID, args); errMark != nil {
+			if errors.Is(errMark, rules.ErrDuplicateSteamID) {
+				return "", fmt.Errorf("%w: %s", errAlreadyMarked, player.Personaname)
+			}
+
+			return "", errMark
+		}
+
+		return fmt.Sprintf("marked %s as %s", player.Personaname, strings.Join(args, ", ")), nil
+	case chatCommandKick:
+		reason := KickReasonCheating
+		if len(args) > 0 {
+			reason = KickReason(strings.ToLower(args[0]))
+			if !reason.valid() {
+				return "", fmt.Errorf("%w: %s", errChatCommandArgs, args[0])
+			}
+		}
+
+		c.overwatch.queue.enqueue(player.SteamID, reason, 0)
+
+		return fmt.Sprintf("queued kick of %s (%s)", player.Personaname, reason), nil
+	case chatCommandWhitelist:
+		if errWl := whitelist(ctx, c.db, c.state, player.SteamID, true); errWl != nil {
+			return "", errWl
+		}
+
+		return fmt.Sprintf("whitelisted %s", player.Personaname), nil
+	default:
+		return "", errChatCommandUnknown
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChatCommand(t *testing.T) {
+	testCases := []struct {
+		message   string
+		isCommand bool
+		expected  chatCommand
+		err       error
+	}{
+		{message: "gg", isCommand: false},
+		{message: "!bd status", isCommand: true, expected: chatCommand{name: chatCommandStatus}},
+		{message: "!BD mark #12 cheater racism", isCommand: true, expected: chatCommand{name: chatCommandMark, userID: 12, args: []string{"cheater", "racism"}}},
+		{message: "!bd kick 7", isCommand: true, expected: chatCommand{name: chatCommandKick, userID: 7, args: []string{}}},
+		{message: "!bd wl 3", isCommand: true, expected: chatCommand{name: chatCommandWhitelist, userID: 3, args: []string{}}},
+		{message: "!bd mark 12", isCommand: true, err: errChatCommandArgs},
+		{message: "!bd kick bob", isCommand: true, err: errChatCommandArgs},
+		{message: "!bd", isCommand: true, err: errChatCommandUnknown},
+		{message: "!bd ban 3", isCommand: true, err: errChatCommandUnknown},
+	}
+
+	for _, testCase := range testCases {
+		command, isCommand, errParse := parseChatCommand(testCase.message)
+		require.Equal(t, testCase.isCommand, isCommand, testCase.message)
+
+		if testCase.err != nil {
+			require.ErrorIs(t, errParse, testCase.err, testCase.message)
+
+			continue
+		}
+
+		require.NoError(t, errParse, testCase.message)
+		require.Equal(t, testCase.expected, command, testCase.message)
+	}
+}
+
+func TestChatCommandSender(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, false)
+	commander := chatCommander{settings: watch.settings, state: watch.state, overwatch: watch}
+
+	us, errUs := watch.state.players.byUserID(1)
+	require.NoError(t, errUs)
+
+	us.Personaname = "player"
+	us.Alive = true
+	watch.state.players.update(us)
+	watch.state.players.update(testKickTarget(76561197970669109, 5, Blu))
+
+	// A bot copying our name must not be able to run commands.
+	impostor := testKickTarget(76561198084134025, 6, Blu)
+	impostor.Personaname = us.Personaname
+	watch.state.players.update(impostor)
+
+	commander.onMessage(ctx, LogEvent{Type: EvtMsg, Player: us.Personaname, Message: "!bd kick 5"})
+	require.Zero(t, watch.queue.len())
+	require.Empty(t, rcon.commands)
+
+	impostor.IsConnected = false
+	watch.state.players.update(impostor)
+
+	// The line says we are dead while we are alive.
+	commander.onMessage(ctx, LogEvent{Type: EvtMsg, Player: us.Personaname, Message: "!bd kick 5", Dead: true})
+	require.Zero(t, watch.queue.len())
+
+	commander.onMessage(ctx, LogEvent{Type: EvtMsg, Player: us.Personaname, Message: "!bd kick 5"})
+	require.Equal(t, 1, watch.queue.len())
+	require.Len(t, rcon.commands, 1)
+}
+
+func TestChatCommandHostileName(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, false)
+	commander := chatCommander{settings: watch.settings, state: watch.state, overwatch: watch}
+
+	us, errUs := watch.state.players.byUserID(1)
+	require.NoError(t, errUs)
+
+	us.Personaname = "player"
+	us.Alive = true
+	watch.state.players.update(us)
+
+	hostile := testKickTarget(76561197970669109, 5, Blu)
+	hostile.Personaname = "bot\"; quit; say \"gg"
+	watch.state.players.update(hostile)
+
+	commander.onMessage(ctx, LogEvent{Type: EvtMsg, Player: us.Personaname, Message: "!bd kick 5"})
+	require.Equal(t, []string{"say_party [bd] queued kick of bot', quit, say 'gg (cheating)"}, rcon.commands)
+}
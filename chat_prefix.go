@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// localizedPrefixes holds the chat prefixes written to the console for a single game language. These come from
+// the TF_Chat_* keys in the game's resource/tf_<language>.txt files.
+type localizedPrefixes struct {
+	team      string // TF_Chat_Team
+	dead      string // TF_Chat_AllDead
+	deadTeam  string // TF_Chat_Team_Dead
+	spectator string // TF_Chat_AllSpec
+	specTeam  string // TF_Chat_Spec
+	coach     string // TF_Chat_Coach
+}
+
+// englishChatPrefixes are always known, even when the game files cannot be read, and match the lines of
+// testdata/console.log.
+var englishChatPrefixes = localizedPrefixes{ //nolint:gochecknoglobals
+	team: "(TEAM) ", dead: "*DEAD* ", deadTeam: "*DEAD*(TEAM) ",
+	spectator: "*SPEC* ", specTeam: "(Spectator) ", coach: "*COACH* ",
+}
+
+// reChatToken matches the TF_Chat_* tokens of a localization file, eg: `"TF_Chat_Team"  "(TEAM) %s1 :  %s2"`.
+// The `[english]TF_Chat_Team` lines of the other languages are only there for reference and do not match.
+var reChatToken = regexp.MustCompile(`^\s*"(TF_Chat_\w+)"\s+"(.*)"`)
+
+// loadChatPrefixes reads the chat prefixes of every language the game has a resource/tf_<language>.txt file
+// for. The english prefixes are always included, files that cannot be read are skipped.
+func loadChatPrefixes(tf2Dir string) []localizedPrefixes {
+	languages := []localizedPrefixes{englishChatPrefixes}
+
+	paths, errGlob := filepath.Glob(filepath.Join(tf2Dir, "resource", "tf_*.txt"))
+	if errGlob != nil {
+		slog.Warn("Failed to find localization files", errAttr(errGlob))
+
+		return languages
+	}
+
+	for _, path := range paths {
+		prefixes, errRead := readChatPrefixes(path)
+		if errRead != nil {
+			if !errors.Is(errRead, errNoChatPrefixes) {
+				slog.Warn("Failed to read chat prefixes", slog.String("path", path), errAttr(errRead))
+			}
+
+			continue
+		}
+
+		languages = append(languages, prefixes)
+	}
+
+	slog.Debug("Loaded chat prefixes", slog.Int("languages", len(languages)))
+
+	return languages
+}
+
+// readChatPrefixes reads the TF_Chat_* tokens of a single localization file. Other files in the resource
+// directory match the same name pattern, those without any chat tokens return errNoChatPrefixes.
+func readChatPrefixes(path string) (localizedPrefixes, error) {
+	body, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return localizedPrefixes{}, errors.Join(errRead, errReadChatPrefixes)
+	}
+
+	var (
+		prefixes localizedPrefixes
+		found    bool
+		scanner  = bufio.NewScanner(strings.NewReader(decodeLocalization(body)))
+	)
+
+	for scanner.Scan() {
+		match := reChatToken.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		prefix, ok := chatTokenPrefix(match[2])
+		if !ok {
+			continue
+		}
+
+		switch match[1] {
+		case "TF_Chat_Team":
+			prefixes.team = prefix
+		case "TF_Chat_AllDead":
+			prefixes.dead = prefix
+		case "TF_Chat_Team_Dead":
+			prefixes.deadTeam = prefix
+		case "TF_Chat_AllSpec":
+			prefixes.spectator = prefix
+		case "TF_Chat_Spec":
+			prefixes.specTeam = prefix
+		case "TF_Chat_Coach":
+			prefixes.coach = prefix
+		default:
+			continue
+		}
+
+		found = true
+	}
+
+	if errScan := scanner.Err(); errScan != nil {
+		return localizedPrefixes{}, errors.Join(errScan, errReadChatPrefixes)
+	}
+
+	if !found {
+		return localizedPrefixes{}, errNoChatPrefixes
+	}
+
+	return prefixes, nil
+}
+
+// chatTokenPrefix returns the part of the chat format before the player name, eg: `(TEAM) ` for
+// `(TEAM) %s1 :  %s2`.
+func chatTokenPrefix(format string) (string, bool) {
+	before, _, found := strings.Cut(format, "%s1")
+
+	return before, found
+}
+
+// decodeLocalization converts the localization files to a string. The game ships them as UTF-16 with a byte
+// order mark, anything else is treated as UTF-8.
+func decodeLocalization(body []byte) string {
+	var littleEndian bool
+
+	switch {
+	case bytes.HasPrefix(body, []byte{0xff, 0xfe}):
+		littleEndian = true
+	case bytes.HasPrefix(body, []byte{0xfe, 0xff}):
+	default:
+		return string(bytes.TrimPrefix(body, []byte{0xef, 0xbb, 0xbf}))
+	}
+
+	body = body[2:]
+	units := make([]uint16, len(body)/2)
+
+	for index := range units {
+		if littleEndian {
+			units[index] = uint16(body[index*2]) | uint16(body[index*2+1])<<8
+		} else {
+			units[index] = uint16(body[index*2])<<8 | uint16(body[index*2+1])
+		}
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// chatPrefix is a single prefix and the flags it implies for the message.
+type chatPrefix struct {
+	value     string
+	team      bool
+	dead      bool
+	spectator bool
+	coach     bool
+}
+
+// newChatPrefixes flattens the localized prefixes of all languages into a single list, ordered longest first so
+// that the combined prefixes such as *DEAD*(TEAM) are matched before their shorter counterparts.
+func newChatPrefixes(languages []localizedPrefixes) []chatPrefix {
+	var prefixes []chatPrefix
+
+	for _, lang := range languages {
+		for _, prefix := range []chatPrefix{
+			{value: lang.team, team: true},
+			{value: lang.dead, dead: true},
+			{value: lang.deadTeam, dead: true, team: true},
+			{value: lang.spectator, spectator: true},
+			{value: lang.specTeam, spectator: true, team: true},
+			{value: lang.coach, coach: true},
+		} {
+			if prefix.value != "" && !slices.Contains(prefixes, prefix) {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+
+	slices.SortFunc(prefixes, func(a, b chatPrefix) int {
+		if n := cmp.Compare(len(b.value), len(a.value)); n != 0 {
+			return n
+		}
+
+		return strings.Compare(a.value, b.value)
+	})
+
+	return prefixes
+}
+
+// trimChatPrefix removes any known chat prefix from the name, returning the flags it corresponds to.
+func trimChatPrefix(prefixes []chatPrefix, name string) (string, chatPrefix) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix.value) {
+			return strings.TrimPrefix(name, prefix.value), prefix
+		}
+	}
+
+	return name, chatPrefix{}
+}
@@ -133,6 +133,7 @@ func getLaunchArgs(rconPass string, rconPort uint16, steamRoot string, steamID s
 		"+hostport", fmt.Sprintf("%d", rconPort),
 		"+net_start",
 		"+con_timestamp", "1",
+		"+exec", "bd_binds",
 		"-rpt", // Same as having -condebug, -conclearlog, and -console enabled
 		"-g15",
 	}
@@ -0,0 +1,205 @@
+package demo
+
+import (
+	"errors"
+	"math"
+)
+
+var errBitsOverflow = errors.New("read past end of buffer")
+
+// bitReader reads the little-endian, lsb first, bit packed data used by the source engine network messages.
+type bitReader struct {
+	data []byte
+	pos  uint
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{data: data}
+}
+
+func (r *bitReader) remaining() uint {
+	return uint(len(r.data))*8 - r.pos
+}
+
+func (r *bitReader) readBit() (bool, error) {
+	if r.remaining() < 1 {
+		return false, errBitsOverflow
+	}
+
+	value := r.data[r.pos/8]>>(r.pos%8)&1 == 1
+	r.pos++
+
+	return value, nil
+}
+
+// readBits reads up to 32 bits as an unsigned integer.
+func (r *bitReader) readBits(count uint) (uint32, error) {
+	if r.remaining() < count {
+		return 0, errBitsOverflow
+	}
+
+	var value uint32
+
+	for i := uint(0); i < count; i++ {
+		if r.data[r.pos/8]>>(r.pos%8)&1 == 1 {
+			value |= 1 << i
+		}
+
+		r.pos++
+	}
+
+	return value, nil
+}
+
+func (r *bitReader) skip(count uint) error {
+	if r.remaining() < count {
+		return errBitsOverflow
+	}
+
+	r.pos += count
+
+	return nil
+}
+
+func (r *bitReader) readByte() (byte, error) {
+	value, err := r.readBits(8)
+
+	return byte(value), err
+}
+
+func (r *bitReader) readUint16() (uint16, error) {
+	value, err := r.readBits(16)
+
+	return uint16(value), err
+}
+
+func (r *bitReader) readUint32() (uint32, error) {
+	return r.readBits(32)
+}
+
+func (r *bitReader) readFloat() (float32, error) {
+	value, err := r.readBits(32)
+
+	return math.Float32frombits(value), err
+}
+
+func (r *bitReader) readBytes(count uint) ([]byte, error) {
+	if r.remaining() < count*8 {
+		return nil, errBitsOverflow
+	}
+
+	if r.pos%8 == 0 {
+		out := make([]byte, count)
+		copy(out, r.data[r.pos/8:])
+		r.pos += count * 8
+
+		return out, nil
+	}
+
+	out := make([]byte, count)
+
+	for i := range out {
+		value, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		out[i] = value
+	}
+
+	return out, nil
+}
+
+// readSubReader returns a new reader over the next count bits.
+func (r *bitReader) readSubReader(count uint) (*bitReader, error) {
+	if r.remaining() < count {
+		return nil, errBitsOverflow
+	}
+
+	data := make([]byte, (count+7)/8)
+	sub := &bitReader{data: r.data, pos: r.pos}
+
+	for i := uint(0); i < count; i++ {
+		bit, _ := sub.readBit()
+		if bit {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	r.pos += count
+
+	return newBitReader(data), nil
+}
+
+// readString reads a null terminated string.
+func (r *bitReader) readString() (string, error) {
+	var out []byte
+
+	for {
+		value, err := r.readByte()
+		if err != nil {
+			return "", err
+		}
+
+		if value == 0 {
+			return string(out), nil
+		}
+
+		out = append(out, value)
+	}
+}
+
+// readCoord reads a single bit packed world coordinate, only the bits are consumed, the value is not needed.
+func (r *bitReader) readCoord() error {
+	const (
+		coordIntegerBits    = 14
+		coordFractionalBits = 5
+	)
+
+	hasInt, errInt := r.readBit()
+	if errInt != nil {
+		return errInt
+	}
+
+	hasFrac, errFrac := r.readBit()
+	if errFrac != nil {
+		return errFrac
+	}
+
+	if !hasInt && !hasFrac {
+		return nil
+	}
+
+	// sign
+	count := uint(1)
+
+	if hasInt {
+		count += coordIntegerBits
+	}
+
+	if hasFrac {
+		count += coordFractionalBits
+	}
+
+	return r.skip(count)
+}
+
+// readVecCoord reads a 3d vector of coordinates, each axis is only present when its flag is set.
+func (r *bitReader) readVecCoord() error {
+	flags, errFlags := r.readBits(3)
+	if errFlags != nil {
+		return errFlags
+	}
+
+	for axis := 0; axis < 3; axis++ {
+		if flags&(1<<axis) == 0 {
+			continue
+		}
+
+		if err := r.readCoord(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+// Package demo implements a minimal reader for TF2 (source engine) .dem files, both POV and STV. Only the data
+// required to find out who was playing and what they said is decoded, everything else is skipped over.
+package demo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+var (
+	ErrInvalidHeader = errors.New("invalid demo header")
+	ErrFrame         = errors.New("invalid demo frame")
+	errReadFrame     = errors.New("failed to read demo frame")
+)
+
+const (
+	demoMagic       = "HL2DEMO\x00"
+	demoStringSize  = 260
+	cmdInfoSize     = 76
+	netMsgTypeBits  = 6
+	maxEdictBits    = 11
+	netMsgLenBits   = 11
+	maxClassBits    = 9
+	maxTableBits    = 5
+	userMessageSay2 = 4
+)
+
+// Demo frame commands.
+const (
+	cmdSignon       = 1
+	cmdPacket       = 2
+	cmdSyncTick     = 3
+	cmdConsoleCmd   = 4
+	cmdUserCmd      = 5
+	cmdDataTables   = 6
+	cmdStop         = 7
+	cmdStringTables = 8
+)
+
+// Header is the fixed size header at the start of every demo file.
+type Header struct {
+	DemoProtocol    int32
+	NetworkProtocol int32
+	ServerName      string
+	ClientName      string
+	MapName         string
+	GameDir         string
+	PlaybackTime    float32
+	Ticks           int32
+	Frames          int32
+	SignonLength    int32
+}
+
+// Player is a unique player seen in the demo.
+type Player struct {
+	SteamID steamid.SteamID
+	Name    string
+	UserID  int
+}
+
+// Message is a single chat message sent by a player.
+type Message struct {
+	Tick    int
+	SteamID steamid.SteamID
+	Name    string
+	Text    string
+	Team    bool
+	Dead    bool
+}
+
+// Demo holds the results of a parsed demo.
+type Demo struct {
+	Header   Header
+	Players  []Player
+	Messages []Message
+	// PacketErrors is how many packets could not be parsed. Anything after the error in those packets, such as
+	// chat messages, is missing from the results. PacketError is the first of the errors.
+	PacketErrors int
+	PacketError  error
+}
+
+// Parse reads a demo in its entirety returning the players and chat messages found.
+func Parse(reader io.Reader) (*Demo, error) {
+	input := bufio.NewReader(reader)
+
+	header, errHeader := readHeader(input)
+	if errHeader != nil {
+		return nil, errHeader
+	}
+
+	state := newParserState()
+
+	for {
+		var frame struct {
+			Cmd  byte
+			Tick int32
+		}
+
+		if err := binary.Read(input, binary.LittleEndian, &frame); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				// Demos of crashed or still running games have no stop frame.
+				break
+			}
+
+			return nil, errors.Join(err, errReadFrame)
+		}
+
+		state.tick = int(frame.Tick)
+
+		switch frame.Cmd {
+		case cmdSignon, cmdPacket:
+			// command info and in/out sequence numbers
+			if _, err := input.Discard(cmdInfoSize + 8); err != nil {
+				return nil, errors.Join(err, errReadFrame)
+			}
+
+			data, errData := readFrameData(input)
+			if errData != nil {
+				return nil, errData
+			}
+
+			// A single bad packet should not stop us from reading the rest of the demo.
+			if err := state.parsePacket(newBitReader(data)); err != nil {
+				state.packetErrors++
+
+				if state.packetError == nil {
+					state.packetError = fmt.Errorf("tick %d: %w", state.tick, err)
+				}
+			}
+		case cmdSyncTick:
+		case cmdConsoleCmd, cmdDataTables:
+			if _, errData := readFrameData(input); errData != nil {
+				return nil, errData
+			}
+		case cmdUserCmd:
+			if _, err := input.Discard(4); err != nil {
+				return nil, errors.Join(err, errReadFrame)
+			}
+
+			if _, errData := readFrameData(input); errData != nil {
+				return nil, errData
+			}
+		case cmdStringTables:
+			data, errData := readFrameData(input)
+			if errData != nil {
+				return nil, errData
+			}
+
+			if err := state.parseStringTablesFrame(newBitReader(data)); err != nil {
+				return nil, err
+			}
+		case cmdStop:
+			return state.result(header), nil
+		default:
+			return nil, ErrFrame
+		}
+	}
+
+	return state.result(header), nil
+}
+
+func readHeader(input io.Reader) (Header, error) {
+	var raw struct {
+		Magic           [8]byte
+		DemoProtocol    int32
+		NetworkProtocol int32
+		ServerName      [demoStringSize]byte
+		ClientName      [demoStringSize]byte
+		MapName         [demoStringSize]byte
+		GameDir         [demoStringSize]byte
+		PlaybackTime    float32
+		Ticks           int32
+		Frames          int32
+		SignonLength    int32
+	}
+
+	if err := binary.Read(input, binary.LittleEndian, &raw); err != nil {
+		return Header{}, errors.Join(err, ErrInvalidHeader)
+	}
+
+	if string(raw.Magic[:]) != demoMagic {
+		return Header{}, ErrInvalidHeader
+	}
+
+	return Header{
+		DemoProtocol:    raw.DemoProtocol,
+		NetworkProtocol: raw.NetworkProtocol,
+		ServerName:      cString(raw.ServerName[:]),
+		ClientName:      cString(raw.ClientName[:]),
+		MapName:         cString(raw.MapName[:]),
+		GameDir:         cString(raw.GameDir[:]),
+		PlaybackTime:    raw.PlaybackTime,
+		Ticks:           raw.Ticks,
+		Frames:          raw.Frames,
+		SignonLength:    raw.SignonLength,
+	}, nil
+}
+
+// maxFrameSize is far larger than any real frame, it only guards against allocating huge buffers for corrupt files.
+const maxFrameSize = 1 << 24
+
+func readFrameData(input io.Reader) ([]byte, error) {
+	var length int32
+	if err := binary.Read(input, binary.LittleEndian, &length); err != nil {
+		return nil, errors.Join(err, errReadFrame)
+	}
+
+	if length < 0 || length > maxFrameSize {
+		return nil, ErrFrame
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(input, data); err != nil {
+		return nil, errors.Join(err, errReadFrame)
+	}
+
+	return data, nil
+}
+
+// cString returns the string up to the first null byte.
+func cString(value []byte) string {
+	if idx := strings.IndexByte(string(value), 0); idx >= 0 {
+		return string(value[:idx])
+	}
+
+	return string(value)
+}
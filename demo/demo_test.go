@@ -0,0 +1,255 @@
+package demo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+type bitWriter struct {
+	data []byte
+	pos  uint
+}
+
+func (w *bitWriter) writeBits(value uint32, count uint) {
+	for i := uint(0); i < count; i++ {
+		if w.pos%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+
+		if value>>i&1 == 1 {
+			w.data[w.pos/8] |= 1 << (w.pos % 8)
+		}
+
+		w.pos++
+	}
+}
+
+func (w *bitWriter) writeBool(value bool) {
+	if value {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+func (w *bitWriter) writeBytes(value []byte) {
+	for _, b := range value {
+		w.writeBits(uint32(b), 8)
+	}
+}
+
+func (w *bitWriter) writeString(value string) {
+	w.writeBytes(append([]byte(value), 0))
+}
+
+func (w *bitWriter) writeVarInt(value uint32) {
+	for value >= 0x80 {
+		w.writeBits(value&0x7F|0x80, 8)
+		value >>= 7
+	}
+
+	w.writeBits(value, 8)
+}
+
+func (w *bitWriter) writeWriter(other *bitWriter) {
+	for i := uint(0); i < other.pos; i++ {
+		w.writeBits(uint32(other.data[i/8]>>(i%8)&1), 1)
+	}
+}
+
+func playerInfo(name string, userID uint32, guid string) []byte {
+	data := make([]byte, 132)
+	copy(data, name)
+	binary.LittleEndian.PutUint32(data[playerInfoUserID:], userID)
+	copy(data[playerInfoGUID:], guid)
+
+	return data
+}
+
+func writeFrame(out *bytes.Buffer, cmd byte, tick int32, data []byte) {
+	out.WriteByte(cmd)
+	_ = binary.Write(out, binary.LittleEndian, tick)
+
+	if cmd == cmdPacket || cmd == cmdSignon {
+		out.Write(make([]byte, cmdInfoSize+8))
+	}
+
+	_ = binary.Write(out, binary.LittleEndian, int32(len(data)))
+	out.Write(data)
+}
+
+func writeHeader(out *bytes.Buffer) {
+	var header struct {
+		Magic           [8]byte
+		DemoProtocol    int32
+		NetworkProtocol int32
+		ServerName      [demoStringSize]byte
+		ClientName      [demoStringSize]byte
+		MapName         [demoStringSize]byte
+		GameDir         [demoStringSize]byte
+		PlaybackTime    float32
+		Ticks           int32
+		Frames          int32
+		SignonLength    int32
+	}
+
+	copy(header.Magic[:], demoMagic)
+	header.DemoProtocol = 3
+	header.NetworkProtocol = 24
+	copy(header.ServerName[:], "127.0.0.1:27015")
+	copy(header.MapName[:], "pl_badwater")
+	copy(header.GameDir[:], "tf")
+	header.PlaybackTime = 10
+
+	_ = binary.Write(out, binary.LittleEndian, header)
+}
+
+func writeSayText2(packet *bitWriter, client byte, key string, name string, text string) {
+	msg := &bitWriter{}
+	msg.writeBits(uint32(client), 8)
+	msg.writeBits(1, 8)
+	msg.writeString(key)
+	msg.writeString(name)
+	msg.writeString(text)
+	msg.writeString("")
+	msg.writeString("")
+
+	packet.writeBits(svcUserMessage, netMsgTypeBits)
+	packet.writeBits(userMessageSay2, 8)
+	packet.writeBits(uint32(msg.pos), netMsgLenBits)
+	packet.writeWriter(msg)
+}
+
+func TestParse(t *testing.T) {
+	var (
+		out     bytes.Buffer
+		player1 = steamid.New("[U:1:238393055]")
+		player2 = steamid.New("[U:1:112236617]")
+	)
+
+	writeHeader(&out)
+
+	// Signon, create the tables with the first player already in the userinfo table.
+	signon := &bitWriter{}
+	signon.writeBits(netTick, netMsgTypeBits)
+	signon.writeBits(100, 32)
+	signon.writeBits(0, 32)
+	signon.writeBits(svcPrint, netMsgTypeBits)
+	signon.writeString("Team Fortress\n")
+	signon.writeBits(netSetConVar, netMsgTypeBits)
+	signon.writeBits(1, 8)
+	signon.writeString("sv_cheats")
+	signon.writeString("0")
+
+	downloadables := &bitWriter{}
+	downloadables.writeBytes([]byte{1, 2, 3})
+
+	signon.writeBits(svcCreateStringTable, netMsgTypeBits)
+	signon.writeString("downloadables")
+	signon.writeBits(8192, 16)
+	signon.writeBits(0, 14)
+	signon.writeVarInt(uint32(downloadables.pos))
+	signon.writeBool(false)
+	signon.writeBool(false)
+	signon.writeWriter(downloadables)
+
+	entries := &bitWriter{}
+	entries.writeBool(true) // sequential
+	entries.writeBool(true) // has name
+	entries.writeBool(false)
+	entries.writeString("2")
+	entries.writeBool(true) // has data
+	entries.writeBits(132, maxUserDataBits)
+	entries.writeBytes(playerInfo("Hassium", 2, "[U:1:238393055]"))
+
+	signon.writeBits(svcCreateStringTable, netMsgTypeBits)
+	signon.writeString(userInfoTable)
+	signon.writeBits(255, 16)
+	signon.writeBits(1, 8)
+	signon.writeVarInt(uint32(entries.pos))
+	signon.writeBool(false)
+	signon.writeBool(false)
+	signon.writeWriter(entries)
+
+	writeFrame(&out, cmdSignon, 0, signon.data)
+
+	// Second player joins and both of them chat.
+	packet := &bitWriter{}
+	packet.writeBits(netTick, netMsgTypeBits)
+	packet.writeBits(200, 32)
+	packet.writeBits(0, 32)
+
+	update := &bitWriter{}
+	update.writeBool(false) // not sequential
+	update.writeBits(4, 7)
+	update.writeBool(true)
+	update.writeBool(false)
+	update.writeString("5")
+	update.writeBool(true)
+	update.writeBits(132, maxUserDataBits)
+	update.writeBytes(playerInfo("Vixian", 5, "[U:1:112236617]"))
+
+	packet.writeBits(svcUpdateStringTable, netMsgTypeBits)
+	packet.writeBits(1, maxTableBits)
+	packet.writeBool(false)
+	packet.writeBits(uint32(update.pos), deltaSizeBits)
+	packet.writeWriter(update)
+
+	packet.writeBits(svcGameEvent, netMsgTypeBits)
+	packet.writeBits(16, netMsgLenBits)
+	packet.writeBits(0xFFFF, 16)
+
+	writeSayText2(packet, 1, "TF_Chat_All", "Hassium", "gg")
+	writeSayText2(packet, 5, "TF_Chat_Team_Dead", "Vixian", "medic pls")
+	writeSayText2(packet, 0, "#TF_Name_Change", "Vixian", "Vix")
+
+	writeFrame(&out, cmdPacket, 200, packet.data)
+
+	// Messages the parser does not know how to skip only lose the rest of their packet.
+	unknown := &bitWriter{}
+	unknown.writeBits(63, netMsgTypeBits)
+	writeFrame(&out, cmdPacket, 250, unknown.data)
+
+	out.WriteByte(cmdStop)
+	_ = binary.Write(&out, binary.LittleEndian, int32(300))
+
+	parsed, errParse := Parse(&out)
+	require.NoError(t, errParse)
+
+	require.Equal(t, "pl_badwater", parsed.Header.MapName)
+	require.Equal(t, "127.0.0.1:27015", parsed.Header.ServerName)
+	require.Equal(t, []Player{
+		{SteamID: player1, Name: "Hassium", UserID: 2},
+		{SteamID: player2, Name: "Vixian", UserID: 5},
+	}, parsed.Players)
+	require.Equal(t, []Message{
+		{Tick: 200, SteamID: player1, Name: "Hassium", Text: "gg"},
+		{Tick: 200, SteamID: player2, Name: "Vixian", Text: "medic pls", Team: true, Dead: true},
+	}, parsed.Messages)
+	require.Equal(t, 1, parsed.PacketErrors)
+	require.ErrorIs(t, parsed.PacketError, errUnknownMessage)
+}
+
+func TestParseInvalidHeader(t *testing.T) {
+	_, errParse := Parse(bytes.NewReader(make([]byte, 2000)))
+	require.ErrorIs(t, errParse, ErrInvalidHeader)
+}
+
+func TestDecompressLZSS(t *testing.T) {
+	// "abcabcabc": 3 literals, a back reference of 6 bytes to offset 3 and the end marker.
+	input := []byte("LZSS")
+	input = binary.LittleEndian.AppendUint32(input, 9)
+	input = append(input, 0b00011000, 'a', 'b', 'c')
+	input = append(input, 0x00, 0x25, 0x00, 0x00)
+
+	output, errDecompress := decompressLZSS(input)
+	require.NoError(t, errDecompress)
+	require.Equal(t, "abcabcabc", string(output))
+
+	_, errInvalid := decompressLZSS([]byte("nope"))
+	require.ErrorIs(t, errInvalid, errLZSS)
+}
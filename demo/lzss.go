@@ -0,0 +1,80 @@
+package demo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errLZSS = errors.New("invalid lzss data")
+
+const (
+	lzssHeaderSize = 8
+	lzssMagic      = "LZSS"
+)
+
+// decompressLZSS decompresses the valve lzss format used for compressed string table data.
+func decompressLZSS(input []byte) ([]byte, error) {
+	if len(input) < lzssHeaderSize || !bytes.Equal(input[:4], []byte(lzssMagic)) {
+		return nil, errLZSS
+	}
+
+	var (
+		actualSize = int(binary.LittleEndian.Uint32(input[4:8]))
+		out        = make([]byte, 0, actualSize)
+		pos        = lzssHeaderSize
+		cmdByte    byte
+		cmdBit     int
+	)
+
+	for {
+		if cmdBit == 0 {
+			if pos >= len(input) {
+				return nil, errLZSS
+			}
+
+			cmdByte = input[pos]
+			pos++
+		}
+
+		cmdBit = (cmdBit + 1) & 0x07
+
+		if cmdByte&1 == 1 {
+			if pos+1 >= len(input) {
+				return nil, errLZSS
+			}
+
+			position := int(input[pos])<<4 | int(input[pos+1]>>4)
+			count := int(input[pos+1]&0x0F) + 1
+			pos += 2
+
+			if count == 1 {
+				break
+			}
+
+			source := len(out) - position - 1
+			if source < 0 {
+				return nil, errLZSS
+			}
+
+			for i := 0; i < count; i++ {
+				out = append(out, out[source+i])
+			}
+		} else {
+			if pos >= len(input) {
+				return nil, errLZSS
+			}
+
+			out = append(out, input[pos])
+			pos++
+		}
+
+		cmdByte >>= 1
+	}
+
+	if len(out) != actualSize {
+		return nil, errLZSS
+	}
+
+	return out, nil
+}
@@ -0,0 +1,439 @@
+package demo
+
+import (
+	"errors"
+	"math/bits"
+	"strings"
+)
+
+var errUnknownMessage = errors.New("unknown net message")
+
+// Net message types for network protocol 24.
+const (
+	netNOP               = 0
+	netDisconnect        = 1
+	netFile              = 2
+	netTick              = 3
+	netStringCmd         = 4
+	netSetConVar         = 5
+	netSignonState       = 6
+	svcPrint             = 7
+	svcServerInfo        = 8
+	svcSendTable         = 9
+	svcClassInfo         = 10
+	svcSetPause          = 11
+	svcCreateStringTable = 12
+	svcUpdateStringTable = 13
+	svcVoiceInit         = 14
+	svcVoiceData         = 15
+	svcSounds            = 17
+	svcSetView           = 18
+	svcFixAngle          = 19
+	svcCrosshairAngle    = 20
+	svcBSPDecal          = 21
+	svcUserMessage       = 23
+	svcEntityMessage     = 24
+	svcGameEvent         = 25
+	svcPacketEntities    = 26
+	svcTempEntities      = 27
+	svcPrefetch          = 28
+	svcMenu              = 29
+	svcGameEventList     = 30
+	svcGetCvarValue      = 31
+	svcCmdKeyValues      = 32
+	deltaSizeBits        = 20
+	maxEventBits         = 9
+	maxDecalIndexBits    = 9
+	modelIndexBits       = 12
+	maxSoundIndexBits    = 14
+	userInfoTable        = "userinfo"
+	voiceQualityCustom   = 255
+)
+
+type parserState struct {
+	tick         int
+	tables       []*stringTable
+	players      map[int]Player
+	seen         []Player
+	messages     []Message
+	packetErrors int
+	packetError  error
+}
+
+func newParserState() *parserState {
+	return &parserState{players: map[int]Player{}}
+}
+
+func (p *parserState) result(header Header) *Demo {
+	return &Demo{
+		Header:       header,
+		Players:      p.seen,
+		Messages:     p.messages,
+		PacketErrors: p.packetErrors,
+		PacketError:  p.packetError,
+	}
+}
+
+// readVarInt32 reads the 7 bits per byte variable length integer encoding.
+func readVarInt32(r *bitReader) (uint32, error) {
+	var value uint32
+
+	for i := 0; i < 5; i++ {
+		part, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+
+		value |= uint32(part&0x7F) << (7 * i)
+
+		if part&0x80 == 0 {
+			break
+		}
+	}
+
+	return value, nil
+}
+
+// log2 is the floor of log2 of value, matching the engines Q_log2.
+func log2(value uint32) uint {
+	if value == 0 {
+		return 0
+	}
+
+	return uint(bits.Len32(value) - 1)
+}
+
+// skipStrings discards the next count null terminated strings.
+func skipStrings(r *bitReader, count int) error {
+	for i := 0; i < count; i++ {
+		if _, err := r.readString(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// skipFields discards a sequence of fixed width fields.
+func skipFields(r *bitReader, sizes ...uint) error {
+	for _, size := range sizes {
+		if err := r.skip(size); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// skipSized reads a length of lengthBits and then skips over that many bits, multiplied by unit.
+func skipSized(r *bitReader, lengthBits uint, unit uint) error {
+	length, err := r.readBits(lengthBits)
+	if err != nil {
+		return err
+	}
+
+	return r.skip(uint(length) * unit)
+}
+
+// parsePacket walks over every net message in the packet. Most of them have no length prefix, so every message
+// type needs to be understood well enough to skip over it.
+func (p *parserState) parsePacket(r *bitReader) error { //nolint:cyclop,gocyclo,maintidx
+	for r.remaining() >= netMsgTypeBits {
+		msgType, errType := r.readBits(netMsgTypeBits)
+		if errType != nil {
+			return errType
+		}
+
+		var err error
+
+		switch msgType {
+		case netNOP:
+		case netDisconnect, netStringCmd, svcPrint:
+			err = skipStrings(r, 1)
+		case netFile:
+			if err = r.skip(32); err == nil {
+				if err = skipStrings(r, 1); err == nil {
+					err = r.skip(1)
+				}
+			}
+		case netTick:
+			err = skipFields(r, 32, 16, 16)
+		case netSetConVar:
+			var count byte
+			if count, err = r.readByte(); err == nil {
+				err = skipStrings(r, int(count)*2)
+			}
+		case netSignonState:
+			err = skipFields(r, 8, 32)
+		case svcServerInfo:
+			if err = skipFields(r, 16, 32, 1, 1, 32, 16, 16*8, 8, 8, 32, 8); err == nil {
+				if err = skipStrings(r, 4); err == nil {
+					err = r.skip(1)
+				}
+			}
+		case svcSendTable:
+			if err = r.skip(1); err == nil {
+				err = skipSized(r, 16, 1)
+			}
+		case svcClassInfo:
+			err = skipClassInfo(r)
+		case svcSetPause:
+			err = r.skip(1)
+		case svcCreateStringTable:
+			err = p.parseCreateStringTable(r)
+		case svcUpdateStringTable:
+			err = p.parseUpdateStringTable(r)
+		case svcVoiceInit:
+			err = skipVoiceInit(r)
+		case svcVoiceData:
+			if err = skipFields(r, 8, 8); err == nil {
+				err = skipSized(r, 16, 1)
+			}
+		case svcSounds:
+			err = skipSounds(r)
+		case svcSetView:
+			err = r.skip(maxEdictBits)
+		case svcFixAngle:
+			err = skipFields(r, 1, 16, 16, 16)
+		case svcCrosshairAngle:
+			err = skipFields(r, 16, 16, 16)
+		case svcBSPDecal:
+			err = skipBSPDecal(r)
+		case svcUserMessage:
+			err = p.parseUserMessage(r)
+		case svcEntityMessage:
+			if err = skipFields(r, maxEdictBits, maxClassBits); err == nil {
+				err = skipSized(r, netMsgLenBits, 1)
+			}
+		case svcGameEvent:
+			err = skipSized(r, netMsgLenBits, 1)
+		case svcPacketEntities:
+			err = skipPacketEntities(r)
+		case svcTempEntities:
+			var length uint32
+			if err = r.skip(8); err == nil {
+				if length, err = readVarInt32(r); err == nil {
+					err = r.skip(uint(length))
+				}
+			}
+		case svcPrefetch:
+			err = r.skip(maxSoundIndexBits)
+		case svcMenu:
+			if err = r.skip(16); err == nil {
+				err = skipSized(r, 16, 8)
+			}
+		case svcGameEventList:
+			if err = r.skip(maxEventBits); err == nil {
+				err = skipSized(r, deltaSizeBits, 1)
+			}
+		case svcGetCvarValue:
+			if err = r.skip(32); err == nil {
+				err = skipStrings(r, 1)
+			}
+		case svcCmdKeyValues:
+			err = skipSized(r, 32, 8)
+		default:
+			return errUnknownMessage
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func skipClassInfo(r *bitReader) error {
+	count, errCount := r.readUint16()
+	if errCount != nil {
+		return errCount
+	}
+
+	createOnClient, errCreate := r.readBit()
+	if errCreate != nil || createOnClient {
+		return errCreate
+	}
+
+	idBits := log2(uint32(count)) + 1
+
+	for i := 0; i < int(count); i++ {
+		if err := r.skip(idBits); err != nil {
+			return err
+		}
+
+		if err := skipStrings(r, 2); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func skipVoiceInit(r *bitReader) error {
+	if err := skipStrings(r, 1); err != nil {
+		return err
+	}
+
+	quality, errQuality := r.readByte()
+	if errQuality != nil {
+		return errQuality
+	}
+
+	if quality == voiceQualityCustom {
+		// sample rate
+		return r.skip(16)
+	}
+
+	return nil
+}
+
+func skipSounds(r *bitReader) error {
+	reliable, errReliable := r.readBit()
+	if errReliable != nil {
+		return errReliable
+	}
+
+	if reliable {
+		return skipSized(r, 8, 1)
+	}
+
+	if err := r.skip(8); err != nil {
+		return err
+	}
+
+	return skipSized(r, 16, 1)
+}
+
+func skipBSPDecal(r *bitReader) error {
+	if err := r.readVecCoord(); err != nil {
+		return err
+	}
+
+	if err := r.skip(maxDecalIndexBits); err != nil {
+		return err
+	}
+
+	hasEntity, errEntity := r.readBit()
+	if errEntity != nil {
+		return errEntity
+	}
+
+	if hasEntity {
+		if err := skipFields(r, maxEdictBits, modelIndexBits); err != nil {
+			return err
+		}
+	}
+
+	// low priority
+	return r.skip(1)
+}
+
+func skipPacketEntities(r *bitReader) error {
+	if err := r.skip(maxEdictBits); err != nil {
+		return err
+	}
+
+	isDelta, errDelta := r.readBit()
+	if errDelta != nil {
+		return errDelta
+	}
+
+	if isDelta {
+		if err := r.skip(32); err != nil {
+			return err
+		}
+	}
+
+	// baseline, updated entries
+	if err := skipFields(r, 1, maxEdictBits); err != nil {
+		return err
+	}
+
+	length, errLength := r.readBits(deltaSizeBits)
+	if errLength != nil {
+		return errLength
+	}
+
+	// update baseline
+	return r.skip(1 + uint(length))
+}
+
+// parseUserMessage records SayText2 chat messages, all other user messages are skipped.
+func (p *parserState) parseUserMessage(r *bitReader) error {
+	msgType, errType := r.readByte()
+	if errType != nil {
+		return errType
+	}
+
+	length, errLength := r.readBits(netMsgLenBits)
+	if errLength != nil {
+		return errLength
+	}
+
+	data, errData := r.readSubReader(uint(length))
+	if errData != nil {
+		return errData
+	}
+
+	if msgType != userMessageSay2 {
+		return nil
+	}
+
+	message, found := p.parseSayText2(data)
+	if found {
+		p.messages = append(p.messages, message)
+	}
+
+	return nil
+}
+
+// parseSayText2 decodes a chat message, eg: client, wants chat, "TF_Chat_All", "name", "message".
+func (p *parserState) parseSayText2(r *bitReader) (Message, bool) {
+	client, errClient := r.readByte()
+	if errClient != nil {
+		return Message{}, false
+	}
+
+	if err := r.skip(8); err != nil {
+		return Message{}, false
+	}
+
+	var fields [3]string
+
+	for i := range fields {
+		value, err := r.readString()
+		if err != nil {
+			return Message{}, false
+		}
+
+		fields[i] = value
+	}
+
+	key := strings.TrimPrefix(fields[0], "#")
+	if !strings.HasPrefix(key, "TF_Chat_") {
+		// Server plugin messages and the like
+		return Message{}, false
+	}
+
+	message := Message{
+		Tick: p.tick,
+		Name: fields[1],
+		Text: fields[2],
+		Team: strings.Contains(key, "Team") || key == "TF_Chat_Spec",
+		Dead: strings.Contains(key, "Dead"),
+	}
+
+	// The client is the entity index of the player, which is offset by one from their userinfo entry.
+	if player, found := p.players[int(client)-1]; found && player.Name == message.Name {
+		message.SteamID = player.SteamID
+	} else {
+		for _, known := range p.seen {
+			if known.Name == message.Name {
+				message.SteamID = known.SteamID
+			}
+		}
+	}
+
+	return message, true
+}
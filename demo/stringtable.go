@@ -0,0 +1,381 @@
+package demo
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+var errStringTable = errors.New("invalid string table")
+
+const (
+	stringHistorySize = 32
+	substringBits     = 5
+	maxUserDataBits   = 14
+	userDataSizeBits  = 12
+	userDataBitsBits  = 4
+
+	// Offsets into the player_info_t struct stored as the userinfo table user data.
+	playerInfoNameLen    = 32
+	playerInfoUserID     = 32
+	playerInfoGUID       = 36
+	playerInfoGUIDLen    = 33
+	playerInfoFriendsID  = 72
+	playerInfoMinimumLen = playerInfoFriendsID + 4
+)
+
+type stringTable struct {
+	name          string
+	maxEntries    uint16
+	userDataFixed bool
+	userDataBits  uint
+}
+
+func (p *parserState) parseCreateStringTable(r *bitReader) error {
+	name, errName := r.readString()
+	if errName != nil {
+		return errName
+	}
+
+	maxEntries, errMax := r.readUint16()
+	if errMax != nil {
+		return errMax
+	}
+
+	entries, errEntries := r.readBits(log2(uint32(maxEntries)) + 1)
+	if errEntries != nil {
+		return errEntries
+	}
+
+	length, errLength := readVarInt32(r)
+	if errLength != nil {
+		return errLength
+	}
+
+	table := &stringTable{name: name, maxEntries: maxEntries}
+
+	fixed, errFixed := r.readBit()
+	if errFixed != nil {
+		return errFixed
+	}
+
+	if fixed {
+		if err := r.skip(userDataSizeBits); err != nil {
+			return err
+		}
+
+		sizeBits, errSizeBits := r.readBits(userDataBitsBits)
+		if errSizeBits != nil {
+			return errSizeBits
+		}
+
+		table.userDataFixed = true
+		table.userDataBits = uint(sizeBits)
+	}
+
+	compressed, errCompressed := r.readBit()
+	if errCompressed != nil {
+		return errCompressed
+	}
+
+	data, errData := r.readSubReader(uint(length))
+	if errData != nil {
+		return errData
+	}
+
+	p.tables = append(p.tables, table)
+
+	if table.name != userInfoTable {
+		return nil
+	}
+
+	if compressed {
+		decompressed, errDecompress := decompressTableData(data)
+		if errDecompress != nil {
+			return errDecompress
+		}
+
+		data = decompressed
+	}
+
+	return p.parseTableEntries(table, data, int(entries))
+}
+
+func (p *parserState) parseUpdateStringTable(r *bitReader) error {
+	tableID, errTableID := r.readBits(maxTableBits)
+	if errTableID != nil {
+		return errTableID
+	}
+
+	changed := uint16(1)
+
+	multiple, errMultiple := r.readBit()
+	if errMultiple != nil {
+		return errMultiple
+	}
+
+	if multiple {
+		count, errCount := r.readUint16()
+		if errCount != nil {
+			return errCount
+		}
+
+		changed = count
+	}
+
+	length, errLength := r.readBits(deltaSizeBits)
+	if errLength != nil {
+		return errLength
+	}
+
+	data, errData := r.readSubReader(uint(length))
+	if errData != nil {
+		return errData
+	}
+
+	if int(tableID) >= len(p.tables) {
+		return errStringTable
+	}
+
+	table := p.tables[tableID]
+	if table.name != userInfoTable {
+		return nil
+	}
+
+	return p.parseTableEntries(table, data, int(changed))
+}
+
+// decompressTableData reads the uncompressed and compressed sizes followed by the lzss compressed data.
+func decompressTableData(r *bitReader) (*bitReader, error) {
+	if err := r.skip(32); err != nil {
+		return nil, err
+	}
+
+	compressedSize, errSize := r.readUint32()
+	if errSize != nil {
+		return nil, errSize
+	}
+
+	compressed, errCompressed := r.readBytes(uint(compressedSize))
+	if errCompressed != nil {
+		return nil, errCompressed
+	}
+
+	decompressed, errDecompress := decompressLZSS(compressed)
+	if errDecompress != nil {
+		return nil, errDecompress
+	}
+
+	return newBitReader(decompressed), nil
+}
+
+// parseTableEntries decodes the delta encoded string table entries. Entry names can reference the prefix of one
+// of the last 32 entries read.
+func (p *parserState) parseTableEntries(table *stringTable, r *bitReader, count int) error {
+	var (
+		encodeBits = log2(uint32(table.maxEntries))
+		lastEntry  = -1
+		history    []string
+	)
+
+	for i := 0; i < count; i++ {
+		entryIndex := lastEntry + 1
+
+		sequential, errSequential := r.readBit()
+		if errSequential != nil {
+			return errSequential
+		}
+
+		if !sequential {
+			index, errIndex := r.readBits(encodeBits)
+			if errIndex != nil {
+				return errIndex
+			}
+
+			entryIndex = int(index)
+		}
+
+		lastEntry = entryIndex
+
+		entry, errEntry := readTableEntryName(r, history)
+		if errEntry != nil {
+			return errEntry
+		}
+
+		hasData, errHasData := r.readBit()
+		if errHasData != nil {
+			return errHasData
+		}
+
+		if hasData {
+			var (
+				userData []byte
+				errRead  error
+			)
+
+			if table.userDataFixed {
+				var value uint32
+				value, errRead = r.readBits(table.userDataBits)
+				userData = binary.LittleEndian.AppendUint32(nil, value)
+			} else {
+				var length uint32
+				if length, errRead = r.readBits(maxUserDataBits); errRead == nil {
+					userData, errRead = r.readBytes(uint(length))
+				}
+			}
+
+			if errRead != nil {
+				return errRead
+			}
+
+			p.applyUserInfo(entryIndex, userData)
+		}
+
+		history = append(history, entry)
+		if len(history) > stringHistorySize {
+			history = history[1:]
+		}
+	}
+
+	return nil
+}
+
+func readTableEntryName(r *bitReader, history []string) (string, error) {
+	hasName, errHasName := r.readBit()
+	if errHasName != nil || !hasName {
+		return "", errHasName
+	}
+
+	isSubstring, errSubstring := r.readBit()
+	if errSubstring != nil {
+		return "", errSubstring
+	}
+
+	if !isSubstring {
+		return r.readString()
+	}
+
+	index, errIndex := r.readBits(substringBits)
+	if errIndex != nil {
+		return "", errIndex
+	}
+
+	length, errLength := r.readBits(substringBits)
+	if errLength != nil {
+		return "", errLength
+	}
+
+	suffix, errSuffix := r.readString()
+	if errSuffix != nil {
+		return "", errSuffix
+	}
+
+	if int(index) >= len(history) || int(length) > len(history[index]) {
+		return "", errStringTable
+	}
+
+	return history[index][:length] + suffix, nil
+}
+
+// parseStringTablesFrame reads the full string table snapshot stored in the dem_stringtables frame.
+func (p *parserState) parseStringTablesFrame(r *bitReader) error {
+	tableCount, errCount := r.readByte()
+	if errCount != nil {
+		return errCount
+	}
+
+	for i := 0; i < int(tableCount); i++ {
+		name, errName := r.readString()
+		if errName != nil {
+			return errName
+		}
+
+		if err := p.readSnapshotEntries(r, name == userInfoTable); err != nil {
+			return err
+		}
+
+		hasClientEntries, errClient := r.readBit()
+		if errClient != nil {
+			return errClient
+		}
+
+		if hasClientEntries {
+			if err := p.readSnapshotEntries(r, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *parserState) readSnapshotEntries(r *bitReader, userInfo bool) error {
+	count, errCount := r.readUint16()
+	if errCount != nil {
+		return errCount
+	}
+
+	for index := 0; index < int(count); index++ {
+		if _, err := r.readString(); err != nil {
+			return err
+		}
+
+		hasData, errHasData := r.readBit()
+		if errHasData != nil {
+			return errHasData
+		}
+
+		if !hasData {
+			continue
+		}
+
+		length, errLength := r.readUint16()
+		if errLength != nil {
+			return errLength
+		}
+
+		userData, errData := r.readBytes(uint(length))
+		if errData != nil {
+			return errData
+		}
+
+		if userInfo {
+			p.applyUserInfo(index, userData)
+		}
+	}
+
+	return nil
+}
+
+// applyUserInfo decodes the player_info_t user data of a userinfo table entry. The entry index is the players
+// entity index minus one.
+func (p *parserState) applyUserInfo(index int, data []byte) {
+	if len(data) < playerInfoMinimumLen {
+		return
+	}
+
+	sid := steamid.New(cString(data[playerInfoGUID : playerInfoGUID+playerInfoGUIDLen]))
+	if !sid.Valid() {
+		// Older demos, fall back to the account id.
+		sid = steamid.New(int64(binary.LittleEndian.Uint32(data[playerInfoFriendsID:])))
+	}
+
+	if !sid.Valid() {
+		// Bots and source tv
+		return
+	}
+
+	player := Player{
+		SteamID: sid,
+		Name:    cString(data[:playerInfoNameLen]),
+		UserID:  int(binary.LittleEndian.Uint32(data[playerInfoUserID:])),
+	}
+
+	p.players[index] = player
+
+	if !slices.Contains(p.seen, player) {
+		p.seen = append(p.seen, player)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/bd/demo"
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// DemoPlayer is a player found in an imported demo along with any rule matches against them.
+type DemoPlayer struct {
+	SteamID  steamid.SteamID     `json:"steam_id"`
+	Name     string              `json:"name"`
+	Messages int                 `json:"messages"`
+	Matches  []rules.MatchResult `json:"matches"`
+}
+
+// DemoImportResult summarises an imported demo.
+type DemoImportResult struct {
+	Source       string       `json:"source"`
+	MapName      string       `json:"map_name"`
+	ServerName   string       `json:"server_name"`
+	ClientName   string       `json:"client_name"`
+	PlaybackTime float32      `json:"playback_time"`
+	Players      []DemoPlayer `json:"players"`
+	// PacketErrors is how many packets of the demo could not be parsed, some chat may be missing when not 0.
+	PacketErrors int `json:"packet_errors"`
+}
+
+// demoDir is where demos are looked up, relative to the tf directory. This is where the demos recorded
+// automatically by the game, and by most demo recording addons, are saved.
+const demoDir = "demos"
+
+// demoPath resolves the name of a demo within the demo directory. Names that are absolute or would escape the
+// demo directory are rejected, so only demos recorded by the game can be opened.
+func demoPath(tf2Dir string, name string) (string, error) {
+	if !strings.EqualFold(filepath.Ext(name), ".dem") {
+		return "", errDemoExtension
+	}
+
+	if !filepath.IsLocal(name) {
+		return "", errDemoPath
+	}
+
+	return filepath.Join(tf2Dir, demoDir, name), nil
+}
+
+// demoSource is stored as the source of names and messages imported from a demo file.
+func demoSource(demoPath string) string {
+	return "demo:" + filepath.Base(demoPath)
+}
+
+// importDemo reads a .dem file from the demo directory and runs the players and chat found through the rules
+// engine. New names and messages are saved to the players history tagged with the demo as their source. Since
+// demos do not record the wall clock time, the file modification time is used as the creation time.
+func importDemo(ctx context.Context, db store.Querier, re *rules.Engine, tf2Dir string, name string,
+) (DemoImportResult, error) {
+	demoPath, errPath := demoPath(tf2Dir, name)
+	if errPath != nil {
+		return DemoImportResult{}, errPath
+	}
+
+	input, errOpen := os.Open(demoPath)
+	if errOpen != nil {
+		return DemoImportResult{}, errors.Join(errOpen, errDemoOpen)
+	}
+
+	defer LogClose(input)
+
+	stat, errStat := input.Stat()
+	if errStat != nil {
+		return DemoImportResult{}, errors.Join(errStat, errDemoOpen)
+	}
+
+	parsed, errParse := demo.Parse(input)
+	if errParse != nil {
+		return DemoImportResult{}, errors.Join(errParse, errDemoParse)
+	}
+
+	result := DemoImportResult{
+		Source:       demoSource(demoPath),
+		MapName:      parsed.Header.MapName,
+		ServerName:   parsed.Header.ServerName,
+		ClientName:   parsed.Header.ClientName,
+		PlaybackTime: parsed.Header.PlaybackTime,
+		Players:      []DemoPlayer{},
+		PacketErrors: parsed.PacketErrors,
+	}
+
+	if parsed.PacketErrors > 0 {
+		slog.Warn("Failed to parse some demo packets", slog.String("source", result.Source),
+			slog.Int("count", parsed.PacketErrors), errAttr(parsed.PacketError))
+	}
+
+	// Players can show up more than once if they changed their name during the match.
+	var (
+		steamIDs steamid.Collection
+		names    = map[steamid.SteamID][]string{}
+	)
+
+	for _, demoPlayer := range parsed.Players {
+		if !slices.Contains(steamIDs, demoPlayer.SteamID) {
+			steamIDs = append(steamIDs, demoPlayer.SteamID)
+		}
+
+		names[demoPlayer.SteamID] = append(names[demoPlayer.SteamID], demoPlayer.Name)
+	}
+
+	for _, steamID := range steamIDs {
+		found, errImport := importDemoPlayer(ctx, db, re, result.Source, stat.ModTime(), steamID, names[steamID], parsed.Messages)
+		if errImport != nil {
+			return result, errImport
+		}
+
+		result.Players = append(result.Players, found)
+	}
+
+	slog.Info("Imported demo", slog.String("source", result.Source),
+		slog.Int("players", len(result.Players)), slog.Int("messages", len(parsed.Messages)))
+
+	return result, nil
+}
+
+func importDemoPlayer(ctx context.Context, db store.Querier, re *rules.Engine, source string, createdOn time.Time,
+	steamID steamid.SteamID, names []string, messages []demo.Message,
+) (DemoPlayer, error) {
+	found := DemoPlayer{SteamID: steamID, Name: names[len(names)-1], Matches: rules.MatchResults{}}
+
+	player, errPlayer := loadPlayerOrCreate(ctx, db, steamID)
+	if errPlayer != nil {
+		return found, errPlayer
+	}
+
+	if player.Personaname == "" {
+		player.Personaname = found.Name
+		if errSave := db.PlayerUpdate(ctx, player.toUpdateParams()); errSave != nil {
+			return found, errors.Join(errSave, errDemoSave)
+		}
+	}
+
+	found.Matches = append(found.Matches, re.MatchSteam(steamID)...)
+
+	knownNames, errNames := db.UserNames(ctx, steamID.Int64())
+	if errNames != nil {
+		return found, errors.Join(errNames, errDemoSave)
+	}
+
+	for _, name := range names {
+		found.Matches = append(found.Matches, re.MatchName(name)...)
+
+		if slices.ContainsFunc(knownNames, func(known store.PlayerName) bool { return known.Name == name }) {
+			continue
+		}
+
+		if errSave := db.UserNameSave(ctx, store.UserNameSaveParams{
+			SteamID:   steamID.Int64(),
+			Name:      name,
+			CreatedOn: createdOn,
+			Source:    source,
+		}); errSave != nil {
+			return found, errors.Join(errSave, errDemoSave)
+		}
+	}
+
+	knownMessages, errMessages := db.Messages(ctx, steamID.Int64())
+	if errMessages != nil {
+		return found, errors.Join(errMessages, errDemoSave)
+	}
+
+	// Importing the same demo again should not duplicate the chat history.
+	alreadyImported := slices.ContainsFunc(knownMessages, func(msg store.PlayerMessage) bool { return msg.Source == source })
+
+	for _, message := range messages {
+		if message.SteamID != steamID {
+			continue
+		}
+
+		found.Messages++
+		found.Matches = append(found.Matches, re.MatchMessage(message.Text)...)
+
+		if alreadyImported {
+			continue
+		}
+
+		if errSave := db.MessageSave(ctx, store.MessageSaveParams{
+			SteamID:   steamID.Int64(),
+			Message:   message.Text,
+			Team:      message.Team,
+			Dead:      message.Dead,
+			CreatedOn: createdOn,
+			Source:    source,
+		}); errSave != nil {
+			return found, errors.Join(errSave, errDemoSave)
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemoPath(t *testing.T) {
+	t.Parallel()
+
+	tf2Dir := filepath.Join(t.TempDir(), "tf")
+
+	for _, testCase := range []struct {
+		name     string
+		expected string
+		err      error
+	}{
+		{name: "pl_upward.dem", expected: filepath.Join(tf2Dir, demoDir, "pl_upward.dem")},
+		{name: "sub/match.DEM", expected: filepath.Join(tf2Dir, demoDir, "sub", "match.DEM")},
+		{name: "match.txt", err: errDemoExtension},
+		{name: "../../../secret.dem", err: errDemoPath},
+		{name: "sub/../../other.dem", err: errDemoPath},
+		{name: filepath.Join(tf2Dir, "abs.dem"), err: errDemoPath},
+	} {
+		path, errPath := demoPath(tf2Dir, testCase.name)
+		if testCase.err != nil {
+			require.ErrorIs(t, errPath, testCase.err, testCase.name)
+
+			continue
+		}
+
+		require.NoError(t, errPath, testCase.name)
+		require.Equal(t, testCase.expected, path)
+	}
+}
@@ -210,7 +210,7 @@ func (d discordState) discordUpdateActivity() error {
 	}
 	server := d.state.CurrentServerState()
 
-	if !server.Addr.IsLinkLocalUnicast() /*SDR*/ && !server.Addr.IsPrivate() && server.Addr != nil && server.Port > 0 {
+	if !server.SDR && server.Addr != nil && !server.Addr.IsPrivate() && server.Port > 0 {
 		u := fmt.Sprintf("steam://connect/%s:%d", server.Addr.String(), server.Port)
 		buttons = append(buttons, &client.Button{
 			Label: "Connect",
@@ -25,6 +25,9 @@
 	errSteamLocalConfig       = errors.New("failed to locate localconfig.vdf")
 	errSteamLaunchArgs        = errors.New("failed to get existing launch options")
 	errLogTailCreate          = errors.New("could not create tail reader")
+	errLogChecksum            = errors.New("could not read log checksum")
+	errReadChatPrefixes       = errors.New("could not read chat prefixes")
+	errNoChatPrefixes         = errors.New("no chat prefixes found")
 	errDuration               = errors.New("failed to parse connected duration")
 	errDataSourceAPIAddr      = errors.New("api data source url invalid")
 	errPlayerListOpen         = errors.New("failed to open player list")
@@ -35,14 +38,29 @@
 	errRCONConnect            = errors.New("failed to connect to game client RCON")
 	errRCONStatus             = errors.New("failed to get status result")
 	errRCONG15                = errors.New("failed to get g15 result")
+	errRCONLobby              = errors.New("failed to get lobby result")
 	errRCONExec               = errors.New("failed to exec rcon command")
 	errRCONRead               = errors.New("failed to read rcon response")
+	errRCONQueueFull          = errors.New("rcon chat queue is full")
+	errChatCommandUnknown     = errors.New("unknown command")
+	errChatCommandArgs        = errors.New("invalid command arguments")
+	errAlreadyMarked          = errors.New("player is already marked")
+	errNoKickTarget           = errors.New("no kick target available")
+	errNoLastKiller           = errors.New("nobody has killed us yet")
 	errG15Parse               = errors.New("failed to parse g15 result")
 	errInvalidChatType        = errors.New("invalid chat destination type")
 	errNotMarked              = errors.New("mark does not exist")
 	errGameStopped            = errors.New("game is not running")
 	errDiscordActivity        = errors.New("failed to set discord activity")
 	errParseTimestamp         = errors.New("failed to parse timestamp")
+	errParsePlayerCount       = errors.New("failed to parse player count")
+	errDemoExtension          = errors.New("demo file must have a .dem extension")
+	errDemoPath               = errors.New("demo file must be within the demo directory")
+	errDemoOpen               = errors.New("failed to open demo file")
+	errDemoParse              = errors.New("failed to parse demo file")
+	errDemoSave               = errors.New("failed to save demo history")
+	errAnnouncementTemplate   = errors.New("invalid announcement template")
+	errQueryAnnouncements     = errors.New("failed to query announcements")
 	errReaderG15              = errors.New("failed to read from g15 reader")
 	errFetchPlayerList        = errors.New("failed to fetch player list")
 	errSettingDirectoryCreate = errors.New("failed to initialize userSettings directory")
@@ -55,6 +73,7 @@
 	errSettingsEncode         = errors.New("failed to encode settings")
 	errHTTPRoutes             = errors.New("failed to setup static routes")
 	errSettingsBDAPIAddr      = errors.New("bd-api address invalid")
+	errSettingLogSource       = errors.New("invalid log source")
 	errResolveAddr            = errors.New("failed to resolve address")
 )
 
@@ -84,8 +103,50 @@
 	EvtTags
 	EvtAddress
 	EvtLobby
+	EvtVersion
+	EvtPlayerCount
+	EvtControl
+	EvtRoundWin
 )
 
+var eventTypeNames = map[EventType]string{ //nolint:gochecknoglobals
+	EvtAny:         "any",
+	EvtKill:        "kill",
+	EvtMsg:         "msg",
+	EvtConnect:     "connect",
+	EvtDisconnect:  "disconnect",
+	EvtStatusID:    "status_id",
+	EvtHostname:    "hostname",
+	EvtMap:         "map",
+	EvtTags:        "tags",
+	EvtAddress:     "address",
+	EvtLobby:       "lobby",
+	EvtVersion:     "version",
+	EvtPlayerCount: "player_count",
+	EvtControl:     "control",
+	EvtRoundWin:    "round_win",
+}
+
+func (e EventType) String() string {
+	name, found := eventTypeNames[e]
+	if !found {
+		return "unknown"
+	}
+
+	return name
+}
+
+// parseEventType returns the EventType matching the name as returned by EventType.String.
+func parseEventType(name string) (EventType, bool) {
+	for eventType, eventName := range eventTypeNames {
+		if eventName == name {
+			return eventType, true
+		}
+	}
+
+	return EvtAny, false
+}
+
 type KickReason string
 
 const (
@@ -95,6 +156,15 @@
 	KickReasonOther    KickReason = "other"
 )
 
+func (reason KickReason) valid() bool {
+	switch reason {
+	case KickReasonIdle, KickReasonScamming, KickReasonCheating, KickReasonOther:
+		return true
+	default:
+		return false
+	}
+}
+
 type ChatDest string
 
 const (
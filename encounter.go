@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// Encounter is the history of every time we shared a server with a player.
+type Encounter struct {
+	SteamID      steamid.SteamID             `json:"steam_id"`
+	FirstSeen    time.Time                   `json:"first_seen"`
+	LastSeen     time.Time                   `json:"last_seen"`
+	PreviousSeen time.Time                   `json:"previous_seen"`
+	TotalTime    int64                       `json:"total_time"`
+	Sessions     int64                       `json:"sessions"`
+	Servers      []store.EncounterServersRow `json:"servers"`
+}
+
+const (
+	// encounterSaveInterval limits how often an ongoing encounter is written, status updates arrive every few seconds.
+	encounterSaveInterval = time.Second * 30
+	// encounterGap is how long a player can be gone before seeing them again counts as a new encounter.
+	encounterGap           = time.Minute * 5
+	encounterPruneInterval = time.Hour
+)
+
+// updateEncounter records that we are currently sharing a server with the player. A new encounter is counted
+// whenever the session changes or the player has not been seen for a while, otherwise the time since they were
+// last seen is added to the total time spent together.
+func (s *gameState) updateEncounter(ctx context.Context, player PlayerState) PlayerState {
+	now := time.Now()
+
+	if now.Sub(player.EncounterUpdatedLast) < encounterSaveInterval {
+		return player
+	}
+
+	settings, errSettings := s.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to read settings", errAttr(errSettings))
+
+		return player
+	}
+
+	if player.SteamID == settings.GetSteamID() {
+		return player
+	}
+
+	s.mu.RLock()
+	sessionID := s.session.SessionID
+	s.mu.RUnlock()
+
+	encounter, errEncounter := s.db.Encounter(ctx, player.SteamID.Int64())
+
+	switch {
+	case errors.Is(errEncounter, sql.ErrNoRows):
+		encounter = store.PlayerEncounter{SteamID: player.SteamID.Int64(), FirstSeen: now, Sessions: 1}
+	case errEncounter != nil:
+		slog.Error("Failed to load encounter", sidAttr(player.SteamID), errAttr(errEncounter))
+
+		return player
+	case encounter.LastSessionID != sessionID || now.Sub(encounter.LastSeen) > encounterGap:
+		encounter.PreviousSeen = encounter.LastSeen
+		encounter.Sessions++
+	default:
+		encounter.TotalTime += int64(now.Sub(encounter.LastSeen).Round(time.Second).Seconds())
+	}
+
+	encounter.LastSeen = now
+	encounter.LastSessionID = sessionID
+
+	if errSave := s.db.EncounterSave(ctx, store.EncounterSaveParams{
+		SteamID:       encounter.SteamID,
+		FirstSeen:     encounter.FirstSeen,
+		LastSeen:      encounter.LastSeen,
+		PreviousSeen:  encounter.PreviousSeen,
+		TotalTime:     encounter.TotalTime,
+		Sessions:      encounter.Sessions,
+		LastSessionID: encounter.LastSessionID,
+	}); errSave != nil {
+		slog.Error("Failed to save encounter", sidAttr(player.SteamID), errAttr(errSave))
+
+		return player
+	}
+
+	player.TimesSeen = encounter.Sessions
+	player.FirstSeenTogether = encounter.FirstSeen
+	player.LastSeenTogether = encounter.PreviousSeen
+	player.TimeTogether = encounter.TotalTime
+	player.EncounterUpdatedLast = now
+
+	return player
+}
+
+// pruneEncounters removes encounters with players we have not seen within the retention period in days. A
+// retention of 0 keeps them forever.
+func (s *gameState) pruneEncounters(ctx context.Context, retention int64) {
+	if retention <= 0 || time.Since(s.encountersPrunedLast) < encounterPruneInterval {
+		return
+	}
+
+	s.encountersPrunedLast = time.Now()
+
+	if err := s.db.EncountersDeleteOlder(ctx, time.Now().AddDate(0, 0, -int(retention))); err != nil {
+		slog.Error("Failed to prune encounters", errAttr(err))
+	}
+}
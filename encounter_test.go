@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateEncounter(t *testing.T) {
+	ctx := context.Background()
+	state, _ := newTestState(t, nil)
+	state.session.SessionID = 1
+
+	player := PlayerState{SteamID: steamid.New(76561198084134025)}
+
+	// First time seeing them.
+	player = state.updateEncounter(ctx, player)
+	require.Equal(t, int64(1), player.TimesSeen)
+	require.True(t, player.LastSeenTogether.IsZero())
+
+	// Updates are only saved periodically.
+	updatedLast := player.EncounterUpdatedLast
+	player = state.updateEncounter(ctx, player)
+	require.Equal(t, updatedLast, player.EncounterUpdatedLast)
+
+	// Still in the same session a minute later.
+	encounter, errEncounter := state.db.Encounter(ctx, player.SteamID.Int64())
+	require.NoError(t, errEncounter)
+
+	lastSeen := time.Now().Add(-time.Minute)
+	require.NoError(t, state.db.EncounterSave(ctx, store.EncounterSaveParams{
+		SteamID:       encounter.SteamID,
+		FirstSeen:     encounter.FirstSeen,
+		LastSeen:      lastSeen,
+		Sessions:      encounter.Sessions,
+		LastSessionID: encounter.LastSessionID,
+	}))
+
+	player.EncounterUpdatedLast = time.Time{}
+	player = state.updateEncounter(ctx, player)
+	require.Equal(t, int64(1), player.TimesSeen)
+	require.Equal(t, int64(60), player.TimeTogether)
+
+	// Seen again in a later session.
+	state.session.SessionID = 2
+	player.EncounterUpdatedLast = time.Time{}
+	player = state.updateEncounter(ctx, player)
+	require.Equal(t, int64(2), player.TimesSeen)
+	require.Equal(t, int64(60), player.TimeTogether)
+	require.False(t, player.LastSeenTogether.IsZero())
+
+	// Expired encounters are removed.
+	require.NoError(t, state.db.EncountersDeleteOlder(ctx, time.Now().Add(time.Minute)))
+
+	_, errMissing := state.db.Encounter(ctx, player.SteamID.Int64())
+	require.Error(t, errMissing)
+}
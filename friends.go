@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+const (
+	// friendOfMarkedMatcher is the matcher type and attribute of the synthetic match given to players with
+	// too many marked friends. Bot operators tend to add all of their accounts as friends.
+	friendOfMarkedMatcher = "friend_of_marked"
+	friendOfMarkedOrigin  = "friends"
+)
+
+// markedFriends returns the friends that match any of the loaded player lists.
+func markedFriends(re *rules.Engine, friends steamid.Collection) steamid.Collection {
+	var marked steamid.Collection
+
+	for _, friend := range friends {
+		if len(re.MatchSteam(friend)) > 0 {
+			marked = append(marked, friend)
+		}
+	}
+
+	return marked
+}
+
+// applyFriendsOfMarked replaces any previous friend_of_marked match of the player with a new one, when they have
+// at least threshold marked friends. A threshold of 0 disables the check.
+func applyFriendsOfMarked(re *rules.Engine, player PlayerState, friends steamid.Collection, threshold int64) PlayerState {
+	matches := slices.DeleteFunc(slices.Clone(player.Matches), func(match rules.MatchResult) bool {
+		return match.MatcherType == friendOfMarkedMatcher
+	})
+
+	if re != nil && threshold > 0 {
+		if marked := markedFriends(re, friends); int64(len(marked)) >= threshold {
+			proof := make([]string, len(marked))
+			for index, friend := range marked {
+				proof[index] = friend.String()
+			}
+
+			matches = append(matches, rules.MatchResult{
+				Origin:      friendOfMarkedOrigin,
+				Attributes:  []string{friendOfMarkedMatcher},
+				Proof:       proof,
+				MatcherType: friendOfMarkedMatcher,
+			})
+
+			slog.Info("Player has marked friends", sidAttr(player.SteamID),
+				slog.String("name", player.Personaname), slog.Int("marked", len(marked)))
+		}
+	}
+
+	player.Matches = matches
+
+	return player
+}
+
+// checkStoredFriends runs the friends of marked check once for a player using the friends list stored from
+// a previous profile update, so that it does not have to wait for the profile to be updated again.
+func (s *gameState) checkStoredFriends(ctx context.Context, player PlayerState) PlayerState {
+	if player.FriendsChecked {
+		return player
+	}
+
+	settings, errSettings := s.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to read settings", errAttr(errSettings))
+
+		return player
+	}
+
+	rows, errFriends := s.db.Friends(ctx, player.SteamID.Int64())
+	if errFriends != nil {
+		slog.Error("Failed to load friends", sidAttr(player.SteamID), errAttr(errFriends))
+
+		return player
+	}
+
+	friends := make(steamid.Collection, len(rows))
+	for index, row := range rows {
+		friends[index] = steamid.New(row.SteamIDFriend)
+	}
+
+	player.FriendsChecked = true
+
+	return applyFriendsOfMarked(s.re, player, friends, settings.FriendsOfMarkedThreshold)
+}
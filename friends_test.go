@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyFriendsOfMarked(t *testing.T) {
+	var (
+		engine  = rules.New()
+		marked1 = steamid.New(76561197961279983)
+		marked2 = steamid.New(76561198084134025)
+		friend  = steamid.New(76561197970669109)
+		player  = PlayerState{SteamID: steamid.New(76561197992870439)}
+	)
+
+	for _, sid := range []steamid.SteamID{marked1, marked2} {
+		require.NoError(t, engine.Mark(rules.MarkOpts{SteamID: sid, Attributes: []string{"cheater"}}))
+	}
+
+	// Below the threshold.
+	player = applyFriendsOfMarked(engine, player, steamid.Collection{marked1, friend}, 2)
+	require.Empty(t, player.Matches)
+
+	player = applyFriendsOfMarked(engine, player, steamid.Collection{marked1, friend, marked2}, 2)
+	require.Len(t, player.Matches, 1)
+	require.Equal(t, friendOfMarkedMatcher, player.Matches[0].MatcherType)
+	require.Equal(t, []string{marked1.String(), marked2.String()}, player.Matches[0].Proof)
+
+	// Checking again replaces the previous match.
+	player = applyFriendsOfMarked(engine, player, steamid.Collection{marked1, marked2}, 2)
+	require.Len(t, player.Matches, 1)
+
+	// Disabled
+	player = applyFriendsOfMarked(engine, player, steamid.Collection{marked1, marked2}, 0)
+	require.Empty(t, player.Matches)
+}
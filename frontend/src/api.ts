@@ -60,11 +60,20 @@ export enum Team {
 export interface Match {
     origin: string;
     attributes: string[];
+    proof?: string[];
     matcher_type: string;
 }
 
 export interface Server {
     server_name: string;
+    addr: string;
+    port: number;
+    sdr: boolean;
+    version: string;
+    secure: boolean;
+    humans: number;
+    bots: number;
+    max_players: number;
     current_map: string;
     tags: string[];
     last_update: string;
@@ -74,6 +83,17 @@ export interface GameState {
     game_running: boolean;
     server: Server;
     players: Player[];
+    parties: Party[];
+}
+
+// connected_together compares the connect times from the status output, the game does not expose real parties.
+export type PartyReason = 'friends' | 'sessions' | 'connected_together';
+
+export interface Party {
+    party_id: number;
+    team: Team;
+    members: string[];
+    reasons: PartyReason[];
 }
 
 const defaultSteamAvatarHash = 'fef49e7fa7e1997310d705b2a6158ff8dc1cdfeb';
@@ -96,6 +116,8 @@ export interface Player {
     last_vac_ban_on: number;
     kills_on: number;
     deaths_by: number;
+    session_kills_on: number;
+    session_deaths_by: number;
     rage_quits: number;
     notes: string;
     whitelist: boolean;
@@ -115,13 +137,136 @@ export interface Player {
     valid: boolean;
     deaths: number;
     kills: number;
+    weapons: Record<string, WeaponUsage>;
     kpm: number;
     kick_attempt_count: number;
+    times_seen: number;
+    first_seen_together: Date;
+    last_seen_together: Date;
+    time_together: number;
+    party_id: number;
+    associated_with: string[] | null;
     our_friend: boolean;
     sourcebans: SourcebansRecord[];
     matches: Match[];
 }
 
+export interface WeaponUsage {
+    kills: number;
+    crits: number;
+}
+
+export interface KillFeedEntry {
+    killer: string;
+    killer_sid: string;
+    victim: string;
+    victim_sid: string;
+    weapon: string;
+    crit: boolean;
+    created_on: string;
+}
+
+export interface ConsumerStats {
+    name: string;
+    policy: 'block' | 'drop_oldest' | 'keep_replayed';
+    queued: number;
+    capacity: number;
+    dropped: number;
+}
+
+export type EventTypeName =
+    | 'kill'
+    | 'msg'
+    | 'connect'
+    | 'disconnect'
+    | 'status_id'
+    | 'hostname'
+    | 'map'
+    | 'tags'
+    | 'address'
+    | 'lobby'
+    | 'version'
+    | 'player_count'
+    | 'control'
+    | 'round_win';
+
+export interface JournalEvent {
+    event_id: number;
+    session_id: number | null;
+    event_type: EventTypeName;
+    player_name: string;
+    steam_id: number;
+    victim_name: string;
+    victim_steam_id: number;
+    message: string;
+    meta_data: string;
+    team: Team;
+    weapon: string;
+    crit: boolean;
+    dead: boolean;
+    team_only: boolean;
+    created_on: string;
+}
+
+export interface JournalQuery {
+    start?: string;
+    end?: string;
+    type?: EventTypeName;
+    steam_id?: string;
+}
+
+export interface EncounterServer {
+    server_name: string;
+    address: string;
+    sessions: number;
+}
+
+export interface Encounter {
+    steam_id: string;
+    first_seen: string;
+    last_seen: string;
+    previous_seen: string;
+    total_time: number;
+    sessions: number;
+    servers: EncounterServer[];
+}
+
+export interface SessionKills {
+    session_id: number;
+    server_name: string;
+    map_name: string;
+    start_time: string;
+    kills_on: number;
+    deaths_by: number;
+}
+
+export interface PlayerProfile {
+    player: Player;
+    sessions: SessionKills[];
+}
+
+export interface SessionPlayer {
+    steam_id: string;
+    name: string;
+    team: Team;
+    score: number;
+    kills_on: number;
+    deaths_by: number;
+    updated_on: string;
+}
+
+export interface GameSession {
+    session_id: number;
+    server_name: string;
+    address: string;
+    map_name: string;
+    tags: string[];
+    start_time: string;
+    end_time: string | null;
+    player_count: number;
+    players: SessionPlayer[];
+}
+
 export interface SourcebansRecord {
     ban_id: number;
     site_name: string;
@@ -188,9 +333,36 @@ export interface UserSettings {
     http_listen_addr: string;
     player_expired_timeout: number;
     player_disconnect_timeout: number;
+    rage_quit_kill_window: number;
+    rage_quit_vote_window: number;
+    log_source: 'file' | 'udp';
+    udp_listen_addr: string;
+    udp_log_secret: number;
+    event_journal_enabled: boolean;
+    event_journal_retention: number;
+    encounter_retention: number;
+    friends_of_marked_threshold: number;
+    rage_quit_round_window: number;
     unique_tags: string[];
 }
 
+export interface DemoPlayer {
+    steam_id: string;
+    name: string;
+    messages: number;
+    matches: Match[];
+}
+
+export interface DemoImportResult {
+    source: string;
+    map_name: string;
+    server_name: string;
+    client_name: string;
+    playback_time: number;
+    players: DemoPlayer[];
+    packet_errors: number;
+}
+
 export interface UserNote {
     note: string;
 }
@@ -210,6 +382,119 @@ export const callVoteMutation = (steam_id: string) => {
     };
 };
 
+export type callVoteOutcome =
+    | ''
+    | 'sent'
+    | 'error';
+
+export interface KickQueueEntry {
+    steam_id: string;
+    name: string;
+    reason: kickReasons;
+    attempts: number;
+    last_attempt: Date | null;
+    outcome: callVoteOutcome;
+}
+
+const getKickQueue = async () =>
+    await callJson<KickQueueEntry[]>('GET', '/api/kickqueue');
+
+export const getKickQueueOptions = () => {
+    return {
+        queryKey: ['kickQueue'],
+        queryFn: getKickQueue
+    };
+};
+
+interface KickQueueRequest {
+    steam_id: string;
+    reason: kickReasons;
+    position?: number;
+}
+
+const addKickQueue = async (request: KickQueueRequest) =>
+    await call<KickQueueRequest>('POST', '/api/kickqueue', request);
+
+export const addKickQueueMutation = () => {
+    return {
+        mutationKey: ['addKickQueue'],
+        mutationFn: async (variables: KickQueueRequest) => {
+            return await addKickQueue(variables);
+        }
+    };
+};
+
+const deleteKickQueue = async (steamId: string) =>
+    await call('DELETE', `/api/kickqueue/${steamId}`);
+
+export const deleteKickQueueMutation = () => {
+    return {
+        mutationKey: ['deleteKickQueue'],
+        mutationFn: async (variables: { steamId: string }) => {
+            return await deleteKickQueue(variables.steamId);
+        }
+    };
+};
+
+export type chatDestination = 'all' | 'team' | 'party';
+
+export type announcementSeverity = 'high' | 'medium' | 'low';
+
+export interface Announcement {
+    severity: announcementSeverity;
+    attributes: string[];
+    destination: chatDestination;
+    template: string;
+    enabled: boolean;
+}
+
+const getAnnouncements = async () =>
+    await callJson<Announcement[]>('GET', '/api/announcements');
+
+export const getAnnouncementsOptions = () => {
+    return {
+        queryKey: ['announcements'],
+        queryFn: getAnnouncements
+    };
+};
+
+const saveAnnouncements = async (announcements: Announcement[]) =>
+    await call<Announcement[]>('PUT', '/api/announcements', announcements);
+
+export const saveAnnouncementsMutation = () => {
+    return {
+        mutationKey: ['saveAnnouncements'],
+        mutationFn: async (variables: { announcements: Announcement[] }) => {
+            return await saveAnnouncements(variables.announcements);
+        }
+    };
+};
+
+interface AnnouncementPreviewRequest {
+    template: string;
+    steam_id: string;
+}
+
+export interface AnnouncementPreview {
+    message: string;
+}
+
+const previewAnnouncement = async (request: AnnouncementPreviewRequest) =>
+    await callJson<AnnouncementPreview, AnnouncementPreviewRequest>(
+        'POST',
+        '/api/announcements/preview',
+        request
+    );
+
+export const previewAnnouncementMutation = () => {
+    return {
+        mutationKey: ['previewAnnouncement'],
+        mutationFn: async (variables: AnnouncementPreviewRequest) => {
+            return await previewAnnouncement(variables);
+        }
+    };
+};
+
 const addWhitelist = async (steamId: string) =>
     await call('POST', `/api/whitelist/${steamId}`);
 
@@ -258,6 +543,20 @@ export const deleteUserNoteMutation = (steamId: string) => {
     };
 };
 
+const importDemo = async (name: string) =>
+    await callJson<DemoImportResult, { name: string }>('POST', '/api/demo', {
+        name
+    });
+
+export const importDemoMutation = () => {
+    return {
+        mutationKey: ['importDemo'],
+        mutationFn: async (variables: { name: string }) => {
+            return await importDemo(variables.name);
+        }
+    };
+};
+
 const markUser = async (steamId: string, attrs: string[]) =>
     await call('POST', `/api/mark/${steamId}`, { attrs });
 
@@ -292,6 +591,151 @@ export const getStateOptions = () => {
     };
 };
 
+const getKillFeed = async () =>
+    await callJson<KillFeedEntry[]>('GET', '/api/killfeed');
+
+export const getKillFeedOptions = () => {
+    return {
+        queryKey: ['killFeed'],
+        queryFn: getKillFeed,
+        refetchInterval: 1000
+    };
+};
+
+const getConsumers = async () =>
+    await callJson<ConsumerStats[]>('GET', '/api/consumers');
+
+export const getConsumersOptions = () => {
+    return {
+        queryKey: ['consumers'],
+        queryFn: getConsumers,
+        refetchInterval: 5000
+    };
+};
+
+const getEvents = async (query: JournalQuery) =>
+    await callJson<JournalEvent[]>(
+        'GET',
+        `/api/events?${new URLSearchParams(Object.entries(query).filter(([, v]) => v !== undefined)).toString()}`
+    );
+
+export const getEventsOptions = (query: JournalQuery) => {
+    return {
+        queryKey: ['events', query],
+        queryFn: async () => await getEvents(query)
+    };
+};
+
+const getSessions = async (steamID?: string) =>
+    await callJson<GameSession[]>(
+        'GET',
+        steamID ? `/api/sessions?steam_id=${steamID}` : '/api/sessions'
+    );
+
+export const getSessionsOptions = (steamID?: string) => {
+    return {
+        queryKey: ['sessions', { steamID }],
+        queryFn: async () => await getSessions(steamID)
+    };
+};
+
+// The vote result is not shown in the console, target_left and target_stayed are inferred from whether the
+// target left while the vote ran.
+export type kickVoteOutcome =
+    | 'pending'
+    | 'target_left'
+    | 'target_stayed'
+    | 'cancelled'
+    | 'rejected';
+
+export interface KickVote {
+    kick_id: number;
+    target_steam_id: string;
+    target_name: string;
+    reason: kickReasons;
+    caller_steam_id: string;
+    caller_name: string;
+    ours: boolean;
+    server_name: string;
+    address: string;
+    start_time: Date;
+    end_time: Date | null;
+    outcome: kickVoteOutcome;
+    message: string;
+}
+
+export interface KickStat {
+    name: string;
+    address?: string;
+    total: number;
+    target_left: number;
+    target_stayed: number;
+    cancelled: number;
+    rejected: number;
+    inferred_success_rate: number;
+}
+
+export interface KickStats {
+    attributes: KickStat[];
+    lists: KickStat[];
+    servers: KickStat[];
+    // Explains that target_left and target_stayed are inferred, suitable for display with the stats.
+    outcome_note: string;
+}
+
+const getKickVotes = async (steamID?: string) =>
+    await callJson<KickVote[]>(
+        'GET',
+        steamID ? `/api/kicks?steam_id=${steamID}` : '/api/kicks'
+    );
+
+export const getKickVotesOptions = (steamID?: string) => {
+    return {
+        queryKey: ['kickVotes', { steamID }],
+        queryFn: async () => await getKickVotes(steamID)
+    };
+};
+
+const getKickStats = async () =>
+    await callJson<KickStats>('GET', '/api/kicks/stats');
+
+export const getKickStatsOptions = () => {
+    return {
+        queryKey: ['kickStats'],
+        queryFn: getKickStats
+    };
+};
+
+const getSession = async (sessionID: number) =>
+    await callJson<GameSession>('GET', `/api/sessions/${sessionID}`);
+
+export const getSessionOptions = (sessionID: number) => {
+    return {
+        queryKey: ['session', { sessionID }],
+        queryFn: async () => await getSession(sessionID)
+    };
+};
+
+const getProfile = async (steamID: string) =>
+    await callJson<PlayerProfile>('GET', `/api/profile/${steamID}`);
+
+export const getProfileOptions = (steamID: string) => {
+    return {
+        queryKey: ['profile', { steamID }],
+        queryFn: async () => await getProfile(steamID)
+    };
+};
+
+const getEncounter = async (steamID: string) =>
+    await callJson<Encounter>('GET', `/api/encounters/${steamID}`);
+
+export const getEncounterOptions = (steamID: string) => {
+    return {
+        queryKey: ['encounter', { steamID }],
+        queryFn: async () => await getEncounter(steamID)
+    };
+};
+
 const getLaunch = async () => await callJson('GET', '/api/launch');
 
 export const getLaunchOptions = () => {
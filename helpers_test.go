@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/leighmacdonald/bd/platform"
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+// testUs is the steam id of the local player in tests.
+var testUs = steamid.New(76561197961279983) //nolint:gochecknoglobals
+
+// mockRcon records the commands sent to it and responds with the next queued response.
+type mockRcon struct {
+	commands  []string
+	responses []string
+}
+
+func (m *mockRcon) exec(_ context.Context, cmd string, _ bool) (string, error) {
+	m.commands = append(m.commands, cmd)
+
+	if len(m.responses) == 0 {
+		return "", nil
+	}
+
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+
+	return resp, nil
+}
+
+// newTestDB creates a migrated database in a temporary directory which is closed when the test ends. The steam id
+// of the local player is set to testUs, configOverrides maps any other config columns to the values to set.
+func newTestDB(t *testing.T, configOverrides map[string]any) *store.Queries {
+	t.Helper()
+
+	conn, errConn := store.Connect(filepath.Join(t.TempDir(), "bd.sqlite"))
+	require.NoError(t, errConn)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	require.NoError(t, store.Migrate(conn))
+
+	overrides := map[string]any{"steam_id": testUs.String()}
+	maps.Copy(overrides, configOverrides)
+
+	columns := make([]string, 0, len(overrides))
+	for column := range overrides {
+		columns = append(columns, column)
+	}
+
+	slices.Sort(columns)
+
+	for _, column := range columns {
+		_, errConfig := conn.ExecContext(context.Background(), "UPDATE config SET "+column+" = ?", overrides[column])
+		require.NoError(t, errConfig, column)
+	}
+
+	return store.New(conn)
+}
+
+// newTestState creates a game state backed by newTestDB, with the rcon commands it sends recorded by the returned
+// mock.
+func newTestState(t *testing.T, configOverrides map[string]any) (*gameState, *mockRcon) {
+	t.Helper()
+
+	var (
+		db   = newTestDB(t, configOverrides)
+		rcon = &mockRcon{}
+	)
+
+	return newGameState(db, newSettingsManager(t.TempDir(), db, platform.New()), newPlayerStates(), rcon, db, nil), rcon
+}
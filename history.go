@@ -14,11 +14,13 @@ type chatRecorder struct {
 
 func newChatRecorder(db store.Querier, ingest *eventBroadcaster) chatRecorder {
 	cr := chatRecorder{
-		incoming: make(chan LogEvent),
+		incoming: make(chan LogEvent, eventQueueSize),
 		db:       db,
 	}
 
-	ingest.registerConsumer(cr.incoming, EvtMsg)
+	// Saving live chat is not worth holding up the rest of the event processing for, but the chat replayed on
+	// startup is what we are recovering and must not be dropped.
+	ingest.registerConsumer("chat", cr.incoming, policyKeepReplayed, EvtMsg)
 
 	return cr
 }
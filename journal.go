@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+const (
+	// journalConfigInterval controls how often the journal settings are reloaded from the database.
+	journalConfigInterval = time.Second * 10
+	journalPruneInterval  = time.Hour
+)
+
+// eventJournal optionally records every broadcast LogEvent into the events table so that past matches can be
+// reviewed after the fact. Events reference the game session that was open when they were recorded, events
+// seen while no session is open, such as the replayed ones, have no session.
+type eventJournal struct {
+	incoming  chan LogEvent
+	db        store.Querier
+	state     *gameState
+	enabled   bool
+	retention int64
+}
+
+// JournalEvent is a single recorded event. SessionID is nil for the events recorded while no session was open.
+type JournalEvent struct {
+	EventID       int64     `json:"event_id"`
+	SessionID     *int64    `json:"session_id"`
+	EventType     string    `json:"event_type"`
+	PlayerName    string    `json:"player_name"`
+	SteamID       int64     `json:"steam_id"`
+	VictimName    string    `json:"victim_name"`
+	VictimSteamID int64     `json:"victim_steam_id"`
+	Message       string    `json:"message"`
+	MetaData      string    `json:"meta_data"`
+	Team          int64     `json:"team"`
+	Weapon        string    `json:"weapon"`
+	Crit          bool      `json:"crit"`
+	Dead          bool      `json:"dead"`
+	TeamOnly      bool      `json:"team_only"`
+	CreatedOn     time.Time `json:"created_on"`
+}
+
+func newJournalEvent(row store.Event) JournalEvent {
+	event := JournalEvent{
+		EventID:       row.EventID,
+		EventType:     row.EventType,
+		PlayerName:    row.PlayerName,
+		SteamID:       row.SteamID,
+		VictimName:    row.VictimName,
+		VictimSteamID: row.VictimSteamID,
+		Message:       row.Message,
+		MetaData:      row.MetaData,
+		Team:          row.Team,
+		Weapon:        row.Weapon,
+		Crit:          row.Crit,
+		Dead:          row.Dead,
+		TeamOnly:      row.TeamOnly,
+		CreatedOn:     row.CreatedOn,
+	}
+
+	if row.SessionID.Valid {
+		event.SessionID = &row.SessionID.Int64
+	}
+
+	return event
+}
+
+func newEventJournal(db store.Querier, state *gameState, broadcaster *eventBroadcaster) *eventJournal {
+	journal := &eventJournal{
+		incoming: make(chan LogEvent, eventQueueSize),
+		db:       db,
+		state:    state,
+	}
+
+	// Like chat, only the replayed events are worth holding up the rest of the event processing for.
+	broadcaster.registerConsumer("journal", journal.incoming, policyKeepReplayed, EvtAny)
+
+	return journal
+}
+
+func (j *eventJournal) start(ctx context.Context) {
+	configTicker := time.NewTicker(journalConfigInterval)
+	defer configTicker.Stop()
+
+	pruneTicker := time.NewTicker(journalPruneInterval)
+	defer pruneTicker.Stop()
+
+	j.reloadConfig(ctx)
+	j.prune(ctx)
+
+	for {
+		select {
+		case evt := <-j.incoming:
+			if !j.enabled {
+				continue
+			}
+
+			if err := j.record(ctx, evt); err != nil {
+				slog.Error("Failed to save journal event", errAttr(err), slog.String("type", evt.Type.String()))
+			}
+		case <-configTicker.C:
+			j.reloadConfig(ctx)
+		case <-pruneTicker.C:
+			j.prune(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (j *eventJournal) reloadConfig(ctx context.Context) {
+	config, errConfig := j.db.Config(ctx)
+	if errConfig != nil {
+		slog.Error("Failed to load journal config", errAttr(errConfig))
+
+		return
+	}
+
+	j.enabled = config.EventJournalEnabled
+	j.retention = config.EventJournalRetention
+}
+
+// prune removes events older than the configured retention period in days. A retention of 0 keeps events
+// forever.
+func (j *eventJournal) prune(ctx context.Context) {
+	if j.retention <= 0 {
+		return
+	}
+
+	if err := j.db.EventsDeleteOlder(ctx, time.Now().AddDate(0, 0, -int(j.retention))); err != nil {
+		slog.Error("Failed to prune journal events", errAttr(err))
+	}
+}
+
+func (j *eventJournal) record(ctx context.Context, evt LogEvent) error {
+	createdOn := evt.Timestamp
+	if createdOn.IsZero() {
+		// tf_lobby_debug output lines are not timestamped.
+		createdOn = time.Now()
+	}
+
+	var sessionID int64
+	if !evt.Replayed {
+		sessionID = j.state.currentSessionID()
+	}
+
+	return j.db.EventSave(ctx, store.EventSaveParams{
+		SessionID:     sql.NullInt64{Int64: sessionID, Valid: sessionID > 0},
+		EventType:     evt.Type.String(),
+		PlayerName:    evt.Player,
+		SteamID:       j.resolve(evt.PlayerSID, evt.Player),
+		VictimName:    evt.Victim,
+		VictimSteamID: j.resolve(evt.VictimSID, evt.Victim),
+		Message:       evt.Message,
+		MetaData:      evt.MetaData,
+		Team:          int64(evt.Team),
+		Weapon:        evt.Weapon,
+		Crit:          evt.Crit,
+		Dead:          evt.Dead,
+		TeamOnly:      evt.TeamOnly,
+		CreatedOn:     createdOn,
+	})
+}
+
+// resolve looks up the steam id of players from their name for the events which do not include one, such as
+// kills and chat. Names shared by several players are left unresolved rather than guessing which one it was.
+func (j *eventJournal) resolve(steamID steamid.SteamID, name string) int64 {
+	if steamID.Valid() || name == "" {
+		return steamID.Int64()
+	}
+
+	player, errPlayer := j.state.players.byUniqueName(name)
+	if errPlayer != nil {
+		return steamID.Int64()
+	}
+
+	return player.SteamID.Int64()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalRecord(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		state, _ = newTestState(t, nil)
+		db       = state.db
+		journal  = newEventJournal(db, state, newEventBroadcaster())
+		killer   = PlayerState{SteamID: steamid.New(76561197961279983), Personaname: "killer", IsConnected: true}
+		victim   = PlayerState{SteamID: steamid.New(76561197960265728), Personaname: "victim", IsConnected: true}
+		now      = time.Now()
+	)
+
+	state.players.update(killer)
+	state.players.update(victim)
+
+	// Events seen before a session opens are not part of any.
+	require.NoError(t, journal.record(ctx, LogEvent{Type: EvtConnect, Player: victim.Personaname, Timestamp: now}))
+
+	state.onHostname(hostnameEvent{hostname: "Uncletopia | Seattle | 1"})
+	state.onMapName(mapEvent{mapName: "pl_badwater"})
+	state.syncSession(ctx)
+	require.NotZero(t, state.currentSessionID())
+
+	// Kill and chat lines only include the names of the players.
+	require.NoError(t, journal.record(ctx, LogEvent{
+		Type: EvtKill, Player: killer.Personaname, Victim: victim.Personaname, Weapon: "scattergun", Timestamp: now,
+	}))
+	require.NoError(t, journal.record(ctx, LogEvent{
+		Type: EvtMsg, Player: victim.Personaname, Message: "nice shot", Timestamp: now,
+	}))
+
+	// Names shared by several players are not resolved.
+	impostor := PlayerState{SteamID: steamid.New(76561198084134025), Personaname: killer.Personaname, IsConnected: true}
+	state.players.update(impostor)
+	require.NoError(t, journal.record(ctx, LogEvent{
+		Type: EvtMsg, Player: killer.Personaname, Message: "gg", Timestamp: now,
+	}))
+
+	query := func(steamID steamid.SteamID, eventType string) []store.Event {
+		events, errEvents := db.Events(ctx, store.EventsParams{
+			StartTime: now.Add(-time.Minute),
+			EndTime:   now.Add(time.Minute),
+			EventType: eventType,
+			SteamID:   steamID.Int64(),
+		})
+		require.NoError(t, errEvents)
+
+		return events
+	}
+
+	killerEvents := query(killer.SteamID, "")
+	require.Len(t, killerEvents, 1)
+	require.Equal(t, EventType(EvtKill).String(), killerEvents[0].EventType)
+	require.Equal(t, victim.SteamID.Int64(), killerEvents[0].VictimSteamID)
+	require.Equal(t, state.currentSessionID(), killerEvents[0].SessionID.Int64)
+
+	victimEvents := query(victim.SteamID, "")
+	require.Len(t, victimEvents, 3)
+	require.Len(t, query(victim.SteamID, EventType(EvtMsg).String()), 1)
+	require.Empty(t, query(impostor.SteamID, ""))
+
+	connect := query(victim.SteamID, EventType(EvtConnect).String())
+	require.Len(t, connect, 1)
+	require.False(t, connect[0].SessionID.Valid)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+type kickRequest struct {
+	steamID     steamid.SteamID
+	reason      KickReason
+	attempts    int
+	lastAttempt time.Time
+	outcome     callVoteResult
+}
+
+// KickQueueEntry is a manually requested kick, along with the results of the votes called so far.
+type KickQueueEntry struct {
+	SteamID     steamid.SteamID `json:"steam_id"`
+	Name        string          `json:"name"`
+	Reason      KickReason      `json:"reason"`
+	Attempts    int             `json:"attempts"`
+	LastAttempt *time.Time      `json:"last_attempt"`
+	Outcome     callVoteResult  `json:"outcome"`
+}
+
+// kickQueue holds the kicks requested by the user, in the order they will be attempted. Entries stay in the
+// queue until the player leaves the game or they are removed by the user.
+type kickQueue struct {
+	mu       *sync.RWMutex
+	requests []kickRequest
+}
+
+func newKickQueue() *kickQueue {
+	return &kickQueue{mu: &sync.RWMutex{}}
+}
+
+// enqueue adds the player at the position given, or moves them there if they are already queued. Positions
+// outside the queue add them to the end.
+func (q *kickQueue) enqueue(steamID steamid.SteamID, reason KickReason, position int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	request := kickRequest{steamID: steamID}
+
+	if index := q.index(steamID); index >= 0 {
+		request = q.requests[index]
+		q.requests = slices.Delete(q.requests, index, index+1)
+	}
+
+	request.reason = reason
+
+	if position < 0 || position > len(q.requests) {
+		position = len(q.requests)
+	}
+
+	q.requests = slices.Insert(q.requests, position, request)
+}
+
+// remove deletes the player from the queue, returning false if they were not queued.
+func (q *kickQueue) remove(steamID steamid.SteamID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := q.index(steamID)
+	if index < 0 {
+		return false
+	}
+
+	q.requests = slices.Delete(q.requests, index, index+1)
+
+	return true
+}
+
+// prune removes the queued players for which present returns false.
+func (q *kickQueue) prune(present func(steamID steamid.SteamID) bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.requests = slices.DeleteFunc(q.requests, func(request kickRequest) bool {
+		return !present(request.steamID)
+	})
+}
+
+// recordAttempt stores the result of a vote called against a queued player. Once a vote has been sent, the
+// player is moved to the end of the queue so the others get their turn.
+func (q *kickQueue) recordAttempt(steamID steamid.SteamID, result callVoteResult) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := q.index(steamID)
+	if index < 0 {
+		return
+	}
+
+	request := q.requests[index]
+	request.attempts++
+	request.lastAttempt = time.Now()
+	request.outcome = result
+
+	if result != callVoteSent {
+		q.requests[index] = request
+
+		return
+	}
+
+	q.requests = append(slices.Delete(q.requests, index, index+1), request)
+}
+
+func (q *kickQueue) entries() []kickRequest {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return slices.Clone(q.requests)
+}
+
+// contains returns true if the player is queued to be kicked.
+func (q *kickQueue) contains(steamID steamid.SteamID) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return q.index(steamID) >= 0
+}
+
+func (q *kickQueue) len() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return len(q.requests)
+}
+
+// index must be called while holding the lock.
+func (q *kickQueue) index(steamID steamid.SteamID) int {
+	return slices.IndexFunc(q.requests, func(request kickRequest) bool {
+		return request.steamID == steamID
+	})
+}
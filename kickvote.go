@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// Kick vote outcomes. Votes are pending until we see the target leave, or the vote times out. The result of the
+// vote is not shown in the console, so whether it passed is inferred from the target leaving while the vote
+// runs. They could also have left on their own or crashed, and a target that stayed might have been protected by
+// a vote immunity rather than a failed vote.
+const (
+	kickVotePending      = "pending"
+	kickVoteTargetLeft   = "target_left"
+	kickVoteTargetStayed = "target_stayed"
+	kickVoteCancelled    = "cancelled"
+	// kickVoteRejected is used when the client did not send the vote at all, the message holds the console output.
+	kickVoteRejected = "rejected"
+)
+
+// Kinds of kick vote tags, used to break down the kick stats.
+const (
+	kickTagAttribute = "attribute"
+	kickTagList      = "list"
+)
+
+// kickVoteTimeout is how long a vote can run before the target is considered to have stayed. This is the servers
+// default sv_vote_timer_duration plus some leeway for the status updates.
+const kickVoteTimeout = time.Second * 25
+
+// KickVote is a single kick vote called on a server, by us or another player.
+type KickVote struct {
+	KickID        int64           `json:"kick_id"`
+	TargetSteamID steamid.SteamID `json:"target_steam_id"`
+	TargetName    string          `json:"target_name"`
+	Reason        KickReason      `json:"reason"`
+	CallerSteamID steamid.SteamID `json:"caller_steam_id"`
+	CallerName    string          `json:"caller_name"`
+	Ours          bool            `json:"ours"`
+	ServerName    string          `json:"server_name"`
+	Address       string          `json:"address"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       *time.Time      `json:"end_time"`
+	Outcome       string          `json:"outcome"`
+	Message       string          `json:"message"`
+}
+
+func newKickVote(row store.KickVote) KickVote {
+	vote := KickVote{
+		KickID:        row.KickID,
+		TargetSteamID: steamid.New(row.TargetSteamID),
+		TargetName:    row.TargetName,
+		Reason:        KickReason(row.Reason),
+		CallerSteamID: steamid.New(row.CallerSteamID),
+		CallerName:    row.CallerName,
+		Ours:          row.Ours,
+		ServerName:    row.ServerName,
+		Address:       row.Address,
+		StartTime:     row.StartTime,
+		Outcome:       row.Outcome,
+		Message:       row.Message,
+	}
+
+	if row.EndTime.Valid {
+		vote.EndTime = &row.EndTime.Time
+	}
+
+	return vote
+}
+
+// KickStat is the number of kick votes for a single attribute, list or server, broken down by outcome.
+// InferredSuccessRate is the share of votes that ran to completion where the target left, rejected and
+// cancelled votes do not affect it. See kickVoteTargetLeft for why it is only an estimate.
+type KickStat struct {
+	Name                string  `json:"name"`
+	Address             string  `json:"address,omitempty"`
+	Total               int64   `json:"total"`
+	TargetLeft          int64   `json:"target_left"`
+	TargetStayed        int64   `json:"target_stayed"`
+	Cancelled           int64   `json:"cancelled"`
+	Rejected            int64   `json:"rejected"`
+	InferredSuccessRate float64 `json:"inferred_success_rate"`
+}
+
+func newKickStat(name string, address string, total int64, targetLeft int64, targetStayed int64, cancelled int64,
+	rejected int64,
+) KickStat {
+	stat := KickStat{
+		Name:         name,
+		Address:      address,
+		Total:        total,
+		TargetLeft:   targetLeft,
+		TargetStayed: targetStayed,
+		Cancelled:    cancelled,
+		Rejected:     rejected,
+	}
+
+	if targetLeft+targetStayed > 0 {
+		stat.InferredSuccessRate = float64(targetLeft) / float64(targetLeft+targetStayed)
+	}
+
+	return stat
+}
+
+// kickOutcomeNote is returned with the kick stats so that the estimates are not mistaken for real vote results.
+const kickOutcomeNote = "Vote results are not shown in the console. target_left and target_stayed are inferred " +
+	"from whether the target left while the vote ran, and may include players that left on their own or were " +
+	"immune to the vote."
+
+// KickStats breaks down the kick vote outcomes by the attributes and lists the target matched, and the server.
+// OutcomeNote explains that the target_left and target_stayed outcomes are inferred.
+type KickStats struct {
+	Attributes  []KickStat `json:"attributes"`
+	Lists       []KickStat `json:"lists"`
+	Servers     []KickStat `json:"servers"`
+	OutcomeNote string     `json:"outcome_note"`
+}
+
+// activeKickVote is the vote currently running on the server. Only one vote can run at a time.
+type activeKickVote struct {
+	kickID    int64
+	target    steamid.SteamID
+	startTime time.Time
+}
+
+// recordKickVote saves a kick vote against the target. Pending votes become the active vote, which is resolved
+// once the target leaves, the vote times out or we leave the server. Any other outcome is final.
+func (s *gameState) recordKickVote(ctx context.Context, caller steamid.SteamID, target PlayerState, reason KickReason,
+	outcome string, message string,
+) {
+	settings, errSettings := s.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to read settings", errAttr(errSettings))
+
+		return
+	}
+
+	var callerName string
+	if callerState, errCaller := s.players.bySteamID(caller); errCaller == nil {
+		callerName = callerState.Personaname
+	}
+
+	now := time.Now()
+	server := s.CurrentServerState()
+	params := store.KickVoteInsertParams{
+		TargetSteamID: target.SteamID.Int64(),
+		TargetName:    target.Personaname,
+		Reason:        string(reason),
+		CallerSteamID: caller.Int64(),
+		CallerName:    callerName,
+		Ours:          caller == settings.GetSteamID(),
+		ServerName:    server.ServerName,
+		Address:       sessionAddress(server),
+		StartTime:     now,
+		Outcome:       outcome,
+		Message:       message,
+	}
+
+	if outcome != kickVotePending {
+		params.EndTime = sql.NullTime{Time: now, Valid: true}
+	} else {
+		// A new vote can only start once the previous one is over, without its target leaving.
+		s.endKickVote(ctx, kickVoteTargetStayed)
+	}
+
+	vote, errInsert := s.db.KickVoteInsert(ctx, params)
+	if errInsert != nil {
+		slog.Error("Failed to save kick vote", sidAttr(target.SteamID), errAttr(errInsert))
+
+		return
+	}
+
+	for _, match := range target.Matches {
+		s.saveKickVoteTag(ctx, vote.KickID, kickTagList, match.Origin)
+
+		for _, attr := range match.Attributes {
+			s.saveKickVoteTag(ctx, vote.KickID, kickTagAttribute, attr)
+		}
+	}
+
+	if outcome == kickVotePending {
+		s.mu.Lock()
+		s.kickVote = activeKickVote{kickID: vote.KickID, target: target.SteamID, startTime: now}
+		s.mu.Unlock()
+	}
+}
+
+func (s *gameState) saveKickVoteTag(ctx context.Context, kickID int64, kind string, value string) {
+	if value == "" {
+		return
+	}
+
+	if errTag := s.db.KickVoteTagSave(ctx, store.KickVoteTagSaveParams{
+		KickID: kickID,
+		Kind:   kind,
+		Value:  value,
+	}); errTag != nil {
+		slog.Error("Failed to save kick vote tag", errAttr(errTag))
+	}
+}
+
+// endKickVote sets the outcome of the active vote, if any.
+func (s *gameState) endKickVote(ctx context.Context, outcome string) {
+	s.mu.Lock()
+	vote := s.kickVote
+	s.kickVote = activeKickVote{}
+	s.mu.Unlock()
+
+	if vote.kickID == 0 {
+		return
+	}
+
+	if errEnd := s.db.KickVoteEnd(ctx, store.KickVoteEndParams{
+		Outcome: outcome,
+		EndTime: sql.NullTime{Time: time.Now(), Valid: true},
+		KickID:  vote.kickID,
+	}); errEnd != nil {
+		slog.Error("Failed to end kick vote", errAttr(errEnd))
+
+		return
+	}
+
+	slog.Info("Kick vote ended", sidAttr(vote.target), slog.String("outcome", outcome))
+}
+
+// onKickTargetLeft ends the active vote when its target is the player that left.
+func (s *gameState) onKickTargetLeft(ctx context.Context, sid steamid.SteamID) {
+	s.mu.RLock()
+	target := s.kickVote.target
+	s.mu.RUnlock()
+
+	if target == sid {
+		s.endKickVote(ctx, kickVoteTargetLeft)
+	}
+}
+
+// expireKickVote ends the active vote once it has run longer than a vote can, with the target still present.
+func (s *gameState) expireKickVote(ctx context.Context, now time.Time) {
+	s.mu.RLock()
+	vote := s.kickVote
+	s.mu.RUnlock()
+
+	if vote.kickID != 0 && now.Sub(vote.startTime) > kickVoteTimeout {
+		s.endKickVote(ctx, kickVoteTargetStayed)
+	}
+}
@@ -1,51 +1,141 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"log/slog"
 	"net"
+	"os"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/leighmacdonald/bd/platform"
+	"github.com/leighmacdonald/bd/store"
 	"github.com/nxadm/tail"
 )
 
+// deliveryPolicy determines what happens when an event is broadcast to a consumer whose queue is full.
+type deliveryPolicy int
+
+const (
+	// policyBlock waits until the consumer has room for the event. Use for consumers that must see every event.
+	policyBlock deliveryPolicy = iota
+	// policyDropOldest discards the oldest queued event to make room for the new one, so a slow consumer
+	// never holds up anyone else.
+	policyDropOldest
+	// policyKeepReplayed waits for room for replayed events like policyBlock, so the burst of lines replayed on
+	// startup is never lost, and drops the oldest queued event like policyDropOldest for live events. Use for
+	// consumers that persist the events.
+	policyKeepReplayed
+)
+
+func (p deliveryPolicy) String() string {
+	switch p {
+	case policyBlock:
+		return "block"
+	case policyDropOldest:
+		return "drop_oldest"
+	case policyKeepReplayed:
+		return "keep_replayed"
+	default:
+		return "unknown"
+	}
+}
+
+// eventConsumer is a single registered receiver of broadcast events.
+type eventConsumer struct {
+	name    string
+	events  chan LogEvent
+	policy  deliveryPolicy
+	dropped atomic.Uint64
+	// Closed when the consumer is unregistered so that blocked sends can give up.
+	done chan struct{}
+}
+
+func (c *eventConsumer) send(logEvent LogEvent) {
+	if c.policy == policyBlock || (c.policy == policyKeepReplayed && logEvent.Replayed) {
+		select {
+		case c.events <- logEvent:
+		case <-c.done:
+		}
+
+		return
+	}
+
+	for {
+		select {
+		case c.events <- logEvent:
+			return
+		default:
+		}
+
+		if cap(c.events) == 0 {
+			// Nothing to drop to make room, so the event itself is dropped.
+			c.dropped.Add(1)
+
+			return
+		}
+
+		select {
+		case <-c.events:
+			c.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// eventQueueSize is the default queue size used for event consumers.
+const eventQueueSize = 256
+
+// ConsumerStats describes the current queue state of a registered event consumer.
+type ConsumerStats struct {
+	Name     string `json:"name"`
+	Policy   string `json:"policy"`
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+	Dropped  uint64 `json:"dropped"`
+}
+
 // eventBroadcaster is responsible for broadcasting incoming parsed log events to any
 // registered consumers.
 type eventBroadcaster struct {
 	// Events are broadcast to any registered consumers
-	eventConsumer map[EventType][]chan LogEvent
+	eventConsumer map[EventType][]*eventConsumer
 	mu            sync.RWMutex
 }
 
 func newEventBroadcaster() *eventBroadcaster {
-	return &eventBroadcaster{eventConsumer: make(map[EventType][]chan LogEvent)}
+	return &eventBroadcaster{eventConsumer: make(map[EventType][]*eventConsumer)}
 }
 
 func (e *eventBroadcaster) broadcast(logEvent LogEvent) {
+	// Copy the consumers so that the lock is not held while sending, which may block.
 	e.mu.RLock()
-	defer e.mu.RUnlock()
-
-	// Emit all events to these consumers
-	for _, consumer := range e.eventConsumer[EvtAny] {
-		consumer <- logEvent
-	}
+	consumers := slices.Concat(e.eventConsumer[EvtAny], e.eventConsumer[logEvent.Type])
+	e.mu.RUnlock()
 
-	// Emit specifically requested events to consumers
-	for _, consumer := range e.eventConsumer[logEvent.Type] {
-		consumer <- logEvent
+	for _, consumer := range consumers {
+		consumer.send(logEvent)
 	}
 }
 
 // registerConsumer can be called to start receiving matching events on the provided LogEvent channel. If no
-// optional EventTypes are provided, it will send all events to that channel by default.
-func (e *eventBroadcaster) registerConsumer(consumer chan LogEvent, eventTypes ...EventType) {
+// optional EventTypes are provided, it will send all events to that channel by default. The channel should be
+// buffered, once it is full the policy decides if the broadcaster waits or drops events. The returned function
+// unregisters the consumer.
+func (e *eventBroadcaster) registerConsumer(name string, consumer chan LogEvent, policy deliveryPolicy,
+	eventTypes ...EventType,
+) func() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -53,19 +143,80 @@ func (e *eventBroadcaster) registerConsumer(consumer chan LogEvent, eventTypes .
 		eventTypes = append(eventTypes, EvtAny)
 	}
 
+	registered := &eventConsumer{
+		name:   name,
+		events: consumer,
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+
 	for _, evtType := range eventTypes {
-		_, found := e.eventConsumer[evtType]
-		if !found {
-			e.eventConsumer[evtType] = []chan LogEvent{}
-		}
+		e.eventConsumer[evtType] = append(e.eventConsumer[evtType], registered)
+	}
+
+	var once sync.Once
 
-		e.eventConsumer[evtType] = append(e.eventConsumer[evtType], consumer)
+	return func() {
+		once.Do(func() {
+			e.unregisterConsumer(registered)
+		})
 	}
 }
 
+func (e *eventBroadcaster) unregisterConsumer(consumer *eventConsumer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for evtType, consumers := range e.eventConsumer {
+		e.eventConsumer[evtType] = slices.DeleteFunc(slices.Clone(consumers), func(c *eventConsumer) bool {
+			return c == consumer
+		})
+	}
+
+	close(consumer.done)
+}
+
+// consumerStats returns the current queue stats for each registered consumer.
+func (e *eventBroadcaster) consumerStats() []ConsumerStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var (
+		stats []ConsumerStats
+		seen  = map[*eventConsumer]bool{}
+	)
+
+	for _, consumers := range e.eventConsumer {
+		for _, consumer := range consumers {
+			if seen[consumer] {
+				continue
+			}
+
+			seen[consumer] = true
+
+			stats = append(stats, ConsumerStats{
+				Name:     consumer.name,
+				Policy:   consumer.policy.String(),
+				Queued:   len(consumer.events),
+				Capacity: cap(consumer.events),
+				Dropped:  consumer.dropped.Load(),
+			})
+		}
+	}
+
+	slices.SortFunc(stats, func(a, b ConsumerStats) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return stats
+}
+
 // logIngest is responsible for reading in log lines from console.log, normalizing them, parsing them and
 // sending them out to the broadcaster for further processing of the events. Lines that do not match
 // known events are discarded.
+//
+// The position of the last processed line is periodically saved so that on the next startup any lines written
+// while bd was not running can be replayed.
 type logIngest struct {
 	tail   *tail.Tail
 	logger *slog.Logger
@@ -73,15 +224,33 @@ type logIngest struct {
 	// Use mostly for testing, allowing simple feeding of an existing console.log file
 	external    chan string
 	broadcaster *eventBroadcaster
+	db          store.Querier
+	path        string
+	// Lines ending at or before this offset existed before we started and are marked as replayed.
+	replayUntil int64
+	offset      int64
+	savedOffset int64
+}
+
+// logLine is a single line read from the log along with the file offset of the end of the line. Lines
+// that did not come from the file have an offset of -1.
+type logLine struct {
+	text   string
+	offset int64
 }
 
-func newLogIngest(path string, parser Parser, echo bool, broadcaster *eventBroadcaster) (*logIngest, error) {
+const (
+	logOffsetSaveInterval = time.Second * 5
+	// logChecksumSize is how much of the content preceding the saved offset is checksummed.
+	logChecksumSize = 256
+)
+
+func newLogIngest(ctx context.Context, path string, parser Parser, echo bool, broadcaster *eventBroadcaster, db store.Querier) (*logIngest, error) {
+	location, replayUntil := resumeLocation(ctx, db, path)
+
 	//goland:noinspection GoBoolExpressions
 	tailConfig := tail.Config{
-		Location: &tail.SeekInfo{
-			Offset: 0,
-			Whence: io.SeekEnd,
-		},
+		Location:  location,
 		Follow:    true,
 		ReOpen:    true,
 		MustExist: false,
@@ -100,10 +269,89 @@ func newLogIngest(path string, parser Parser, echo bool, broadcaster *eventBroad
 		parser:      parser,
 		broadcaster: broadcaster,
 		external:    make(chan string),
+		db:          db,
+		path:        path,
+		replayUntil: replayUntil,
+		offset:      -1,
+		savedOffset: -1,
 	}, nil
 }
 
-func (li *logIngest) lineEmitter(ctx context.Context, incoming chan string) {
+// logChecksum calculates the checksum of up to logChecksumSize bytes of the file preceding the offset. Comparing
+// this with the checksum saved along with the offset tells us if the content before the offset has been replaced.
+func logChecksum(path string, offset int64) (int64, error) {
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		return 0, errors.Join(errOpen, errLogChecksum)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	start := max(offset-logChecksumSize, 0)
+	content := make([]byte, offset-start)
+
+	if _, errRead := file.ReadAt(content, start); errRead != nil {
+		return 0, errors.Join(errRead, errLogChecksum)
+	}
+
+	return int64(crc32.ChecksumIEEE(content)), nil
+}
+
+// resumeLocation determines where to start reading the log from. If we have a saved offset for the same
+// file, reading resumes from there. If the file has been replaced or truncated, such as when the game is
+// launched with -conclearlog, the whole file is read. A log truncated in place can grow back past the saved
+// offset, so the size and the content preceding the offset must also match what was saved. Otherwise, we start
+// at the end of the file like a normal tail. The returned offset is the end of the existing content which will
+// be replayed.
+func resumeLocation(ctx context.Context, db store.Querier, path string) (*tail.SeekInfo, int64) {
+	tailEnd := &tail.SeekInfo{Offset: 0, Whence: io.SeekEnd}
+
+	info, errStat := os.Stat(path)
+	if errStat != nil {
+		return tailEnd, 0
+	}
+
+	saved, errSaved := db.LogOffset(ctx, path)
+	if errSaved != nil {
+		if !errors.Is(errSaved, sql.ErrNoRows) {
+			slog.Error("Failed to load saved log offset", errAttr(errSaved))
+		}
+
+		return tailEnd, 0
+	}
+
+	fileID, errFileID := platform.FileID(path)
+	if errFileID != nil {
+		slog.Warn("Failed to read log file id", errAttr(errFileID))
+	}
+
+	rotated := info.Size() < saved.FileOffset ||
+		info.Size() < saved.FileSize ||
+		info.ModTime().Before(saved.ModTime) ||
+		(saved.Inode != 0 && fileID != 0 && saved.Inode != int64(fileID))
+
+	if !rotated && saved.Checksum != 0 {
+		checksum, errChecksum := logChecksum(path, saved.FileOffset)
+		if errChecksum != nil {
+			slog.Warn("Failed to read log checksum", errAttr(errChecksum))
+		}
+
+		rotated = checksum != saved.Checksum
+	}
+
+	if rotated {
+		slog.Info("Log file changed, replaying from start", slog.Int64("size", info.Size()))
+
+		return &tail.SeekInfo{Offset: 0, Whence: io.SeekStart}, info.Size()
+	}
+
+	slog.Info("Resuming log from saved offset",
+		slog.Int64("offset", saved.FileOffset), slog.Int64("size", info.Size()))
+
+	return &tail.SeekInfo{Offset: saved.FileOffset, Whence: io.SeekStart}, info.Size()
+}
+
+func (li *logIngest) lineEmitter(ctx context.Context, incoming chan logLine) {
 	for {
 		select {
 		case msg := <-li.tail.Lines:
@@ -117,39 +365,90 @@ func (li *logIngest) lineEmitter(ctx context.Context, incoming chan string) {
 				continue
 			}
 
-			incoming <- line
+			incoming <- logLine{text: line, offset: msg.SeekInfo.Offset}
 		case externalLine := <-li.external:
 			line := strings.TrimSuffix(externalLine, "\r")
 			if line == "" {
 				continue
 			}
-			incoming <- line
+			incoming <- logLine{text: line, offset: -1}
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// saveOffset persists the offset of the last processed line along with the identity of the file it belongs to.
+func (li *logIngest) saveOffset(ctx context.Context) {
+	if li.offset < 0 || li.offset == li.savedOffset {
+		return
+	}
+
+	info, errStat := os.Stat(li.path)
+	if errStat != nil {
+		return
+	}
+
+	fileID, errFileID := platform.FileID(li.path)
+	if errFileID != nil {
+		li.logger.Warn("Failed to read log file id", errAttr(errFileID))
+	}
+
+	checksum, errChecksum := logChecksum(li.path, li.offset)
+	if errChecksum != nil {
+		li.logger.Warn("Failed to read log checksum", errAttr(errChecksum))
+	}
+
+	if errSave := li.db.LogOffsetSave(ctx, store.LogOffsetSaveParams{
+		Path:       li.path,
+		FileOffset: li.offset,
+		FileSize:   info.Size(),
+		Inode:      int64(fileID),
+		ModTime:    info.ModTime(),
+		UpdatedOn:  time.Now(),
+		Checksum:   checksum,
+	}); errSave != nil {
+		li.logger.Error("Failed to save log offset", errAttr(errSave))
+
+		return
+	}
+
+	li.savedOffset = li.offset
+}
+
 // start begins reading incoming log events, parsing events from the lines and emitting any found events as a LogEvent.
 func (li *logIngest) start(ctx context.Context) {
 	defer li.tail.Cleanup()
-	incomingLogLines := make(chan string)
+
+	incomingLogLines := make(chan logLine)
+	saveTicker := time.NewTicker(logOffsetSaveInterval)
 
 	go li.lineEmitter(ctx, incomingLogLines)
 
 	for {
 		select {
 		case line := <-incomingLogLines:
+			if line.offset >= 0 {
+				li.offset = line.offset
+			}
+
 			var logEvent LogEvent
-			if err := li.parser.parse(line, &logEvent); err != nil || errors.Is(err, ErrNoMatch) {
+			if err := li.parser.parse(line.text, &logEvent); err != nil || errors.Is(err, ErrNoMatch) {
 				// slog.Debug("could not match line", slog.String("line", line))
 				continue
 			}
 
-			slog.Debug("matched line", slog.String("line", line))
+			logEvent.Replayed = line.offset >= 0 && line.offset <= li.replayUntil
+
+			slog.Debug("matched line", slog.String("line", line.text))
 
 			li.broadcaster.broadcast(logEvent)
+		case <-saveTicker.C:
+			li.saveOffset(ctx)
 		case <-ctx.Done():
+			// The parent context is already cancelled at this point.
+			li.saveOffset(context.Background())
+
 			if errStop := li.tail.Stop(); errStop != nil {
 				li.logger.Error("Failed to stop tailing console.log cleanly", errAttr(errStop))
 			}
@@ -162,23 +461,66 @@ func (li *logIngest) start(ctx context.Context) {
 type srcdsPacket byte
 
 const (
-	// Normal log messages (unsupported).
+	// Normal log messages, sent when sv_logsecret is not set.
 	s2aLogString srcdsPacket = 0x52
 	// Sent when using sv_logsecret.
 	s2aLogString2 srcdsPacket = 0x53
 )
 
+var (
+	errLogPacketHeader = errors.New("invalid log packet header")
+	errLogPacketType   = errors.New("unsupported log packet type")
+	errLogPacketMarker = errors.New("failed to find log line marker")
+	errLogPacketSecret = errors.New("invalid log packet secret")
+)
+
+// parseLogPacket extracts the log line from a srcds log packet. When secret is non-zero, only packets
+// sent with a matching sv_logsecret are accepted. The leading `L ` marker is removed so that the line shares the
+// timestamp format written to console.log, the srcds formatted kill and chat lines are handled by the logParser.
+func parseLogPacket(packet []byte, secret int64) (string, error) {
+	if len(packet) < 6 || !bytes.Equal(packet[:4], []byte{0xff, 0xff, 0xff, 0xff}) {
+		return "", errLogPacketHeader
+	}
+
+	body := string(packet[5:])
+
+	idx := strings.Index(body, "L ")
+	if idx == -1 {
+		return "", errLogPacketMarker
+	}
+
+	switch srcdsPacket(packet[4]) {
+	case s2aLogString:
+		if secret != 0 {
+			return "", errLogPacketSecret
+		}
+	case s2aLogString2:
+		packetSecret, errConv := strconv.ParseInt(body[:idx], 10, 64)
+		if errConv != nil {
+			return "", errors.Join(errConv, errLogPacketSecret)
+		}
+
+		if secret != 0 && packetSecret != secret {
+			return "", errLogPacketSecret
+		}
+	default:
+		return "", errLogPacketType
+	}
+
+	return strings.TrimRight(body[idx+2:], "\x00\r\n"), nil
+}
+
 // udpListener can be used to receive log lines using the standard srcds remote UDP logging subsystem (logaddress_add ip:port).
 // This is designed to allow receiving logs from a remote system. Most users should not be using this unless they
-// know what they are doing. Beware this functionality has not really tested and this exists mostly as a theoretical
-// implementation, however the relevant functionality was pulled from a known working system.
+// know what they are doing.
 type udpListener struct {
 	udpAddr     *net.UDPAddr
+	secret      int64
 	broadcaster *eventBroadcaster
-	parser      *logParser
+	parser      Parser
 }
 
-func newUDPListener(logAddr string, parser *logParser, broadcaster *eventBroadcaster) (*udpListener, error) {
+func newUDPListener(logAddr string, secret int64, parser Parser, broadcaster *eventBroadcaster) (*udpListener, error) {
 	udpAddr, errResolveUDP := net.ResolveUDPAddr("udp4", logAddr)
 	if errResolveUDP != nil {
 		return nil, errors.Join(errResolveUDP, errResolveAddr)
@@ -186,20 +528,14 @@ func newUDPListener(logAddr string, parser *logParser, broadcaster *eventBroadca
 
 	return &udpListener{
 		udpAddr:     udpAddr,
+		secret:      secret,
 		broadcaster: broadcaster,
 		parser:      parser,
 	}, nil
 }
 
-// Start initiates the udp network log read loop. DNS names are used to
-// map the server logs to the internal known server id. The DNS is updated
-// every 60 minutes so that it remains up to date.
+// start opens the udp socket and begins reading incoming log packets until the context is cancelled.
 func (l *udpListener) start(ctx context.Context) {
-	type newMsg struct {
-		source int64
-		body   string
-	}
-
 	connection, errListenUDP := net.ListenUDP("udp4", l.udpAddr)
 	if errListenUDP != nil {
 		slog.Error("Failed to start log listener", errAttr(errListenUDP))
@@ -207,37 +543,31 @@ type newMsg struct {
 		return
 	}
 
-	defer func() {
-		if errConnClose := connection.Close(); errConnClose != nil {
-			slog.Error("Failed to close connection cleanly", errAttr(errConnClose))
-		}
-	}()
-
 	slog.Info("Starting log reader",
 		slog.String("listen_addr", fmt.Sprintf("%s/udp", l.udpAddr.String())))
 
-	var (
-		count          = uint64(0)
-		insecureCount  = uint64(0)
-		errCount       = uint64(0)
-		msgIngressChan = make(chan newMsg)
-	)
+	l.serve(ctx, connection)
+}
 
+// serve reads log packets from the connection, parsing the log lines and broadcasting any matched events.
+// The connection is closed once the context is cancelled.
+func (l *udpListener) serve(ctx context.Context, connection *net.UDPConn) {
 	go func() {
 		// Close the listener on context cancellation
 		<-ctx.Done()
-		if errClose := connection.Close(); errClose != nil {
+		if errClose := connection.Close(); errClose != nil && !errors.Is(errClose, net.ErrClosed) {
 			slog.Error("failed to close udp connection cleanly", errAttr(errClose))
 		}
 	}()
 
-	startTime := time.Now()
-	buffer := make([]byte, 1024)
+	var (
+		count     = uint64(0)
+		errCount  = uint64(0)
+		startTime = time.Now()
+		buffer    = make([]byte, 1024)
+	)
 
 	for {
-		// Reuse memory
-		clear(buffer)
-
 		readLen, _, errReadUDP := connection.ReadFromUDP(buffer)
 		if errReadUDP != nil {
 			if errors.Is(errReadUDP, net.ErrClosed) {
@@ -249,51 +579,36 @@ type newMsg struct {
 			continue
 		}
 
-		switch srcdsPacket(buffer[4]) {
-		case s2aLogString:
-			if insecureCount%10000 == 0 {
-				slog.Error("Using unsupported log packet type 0x52",
-					slog.Int64("count", int64(insecureCount+1)))
+		line, errPacket := parseLogPacket(buffer[:readLen], l.secret)
+		if errPacket != nil {
+			if errCount%10000 == 0 {
+				slog.Warn("Received invalid log packet", errAttr(errPacket),
+					slog.Uint64("errors", errCount+1))
 			}
 
-			insecureCount++
 			errCount++
-		case s2aLogString2:
-			line := string(buffer)
-
-			idx := strings.Index(line, "L ")
-			if idx == -1 {
-				slog.Warn("Received malformed log message: Failed to find marker")
-
-				errCount++
-
-				continue
-			}
-
-			secret, errConv := strconv.ParseInt(line[5:idx], 10, 32)
-			if errConv != nil {
-				slog.Error("Received malformed log message: Failed to parse secret",
-					errAttr(errConv))
 
-				errCount++
-
-				continue
-			}
+			continue
+		}
 
-			msgIngressChan <- newMsg{source: secret, body: line[idx : readLen-2]}
+		count++
 
-			count++
+		if count%10000 == 0 {
+			rate := float64(count) / time.Since(startTime).Seconds()
 
-			if count%10000 == 0 {
-				rate := float64(count) / time.Since(startTime).Seconds()
+			slog.Debug("UDP SRCDS Logger Packets",
+				slog.Uint64("count", count),
+				slog.Float64("messages/sec", rate),
+				slog.Uint64("errors", errCount))
 
-				slog.Debug("UDP SRCDS Logger Packets",
-					slog.Uint64("count", count),
-					slog.Float64("messages/sec", rate),
-					slog.Uint64("errors", errCount))
+			startTime = time.Now()
+		}
 
-				startTime = time.Now()
-			}
+		var logEvent LogEvent
+		if errParse := l.parser.parse(line, &logEvent); errParse != nil {
+			continue
 		}
+
+		l.broadcaster.broadcast(logEvent)
 	}
 }
@@ -1,9 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf16"
 
+	"github.com/leighmacdonald/bd/platform"
+	"github.com/leighmacdonald/bd/store"
 	"github.com/leighmacdonald/steamid/v4/steamid"
 	"github.com/stretchr/testify/require"
 )
@@ -36,12 +46,12 @@ type tc struct {
 		{
 			text:     "02/24/2023 - 23:37:19: ❤ Ashley ❤ killed [TrC] Nosy with spy_cicle.",
 			match:    true,
-			expected: LogEvent{Type: EvtKill, Player: "❤ Ashley ❤", Victim: "[TrC] Nosy", Timestamp: timeStamp},
+			expected: LogEvent{Type: EvtKill, Player: "❤ Ashley ❤", Victim: "[TrC] Nosy", Weapon: "spy_cicle", Timestamp: timeStamp},
 		},
 		{
 			text:     "02/24/2023 - 23:37:19: ❤ Ashley ❤ killed [TrC] Nosy with spy_cicle. (crit)",
 			match:    true,
-			expected: LogEvent{Type: EvtKill, Player: "❤ Ashley ❤", Victim: "[TrC] Nosy", Timestamp: timeStamp},
+			expected: LogEvent{Type: EvtKill, Player: "❤ Ashley ❤", Victim: "[TrC] Nosy", Weapon: "spy_cicle", Crit: true, Timestamp: timeStamp},
 		},
 		{
 			text:     "02/24/2023 - 23:37:19: Hassium connected",
@@ -84,6 +94,66 @@ type tc struct {
 			match:    true,
 			expected: LogEvent{Type: EvtAddress, Timestamp: timeStamp, MetaData: "74.91.117.2:27015"},
 		},
+		{
+			text:     "02/24/2023 - 23:37:19: version : 7961495/24 7961495 secure",
+			match:    true,
+			expected: LogEvent{Type: EvtVersion, Timestamp: timeStamp, MetaData: "7961495/24 7961495 secure"},
+		},
+		{
+			text:     "02/24/2023 - 23:37:19: players : 14 humans, 0 bots (32 max)",
+			match:    true,
+			expected: LogEvent{Type: EvtPlayerCount, Timestamp: timeStamp, MetaData: "14 humans, 0 bots (32 max)"},
+		},
+		{
+			text:     "02/24/2023 - 23:37:19: __bd_control__ kick_next",
+			match:    true,
+			expected: LogEvent{Type: EvtControl, Timestamp: timeStamp, MetaData: "kick_next"},
+		},
+		{
+			text:     `02/24/2023 - 23:37:19: World triggered "Round_Win" (winner "Blue")`,
+			match:    true,
+			expected: LogEvent{Type: EvtRoundWin, Timestamp: timeStamp, Team: Blu},
+		},
+		{
+			text:  `02/24/2023 - 23:37:19: "Hassium<12><[U:1:238393055]><Red>" killed "[TrC] Nosy<13><[U:1:123]><Blue>" with "scattergun" (attacker_position "-1543 2291 -223") (victim_position "-1389 2412 -223")`,
+			match: true,
+			expected: LogEvent{
+				Type: EvtKill, Timestamp: timeStamp, Player: "Hassium", PlayerSID: steamid.New("[U:1:238393055]"), Team: Red,
+				Victim: "[TrC] Nosy", VictimSID: steamid.New("[U:1:123]"), Weapon: "scattergun",
+			},
+		},
+		{
+			text:  `02/24/2023 - 23:37:19: "❤ Ashley ❤<12><[U:1:238393055]><Blue>" killed "Nosy<13><[U:1:123]><Red>" with "tf_projectile_rocket" (customkill "headshot") (crit "crit") (attacker_position "1 2 3") (victim_position "4 5 6")`,
+			match: true,
+			expected: LogEvent{
+				Type: EvtKill, Timestamp: timeStamp, Player: "❤ Ashley ❤", PlayerSID: steamid.New("[U:1:238393055]"), Team: Blu,
+				Victim: "Nosy", VictimSID: steamid.New("[U:1:123]"), Weapon: "tf_projectile_rocket", Crit: true,
+			},
+		},
+		{
+			text:     `02/24/2023 - 23:37:19: "Hassium<12><[U:1:238393055]><Red>" say "gg :  no re"`,
+			match:    true,
+			expected: LogEvent{Type: EvtMsg, Timestamp: timeStamp, Player: "Hassium", PlayerSID: steamid.New("[U:1:238393055]"), Team: Red, Message: "gg :  no re"},
+		},
+		{
+			text:  `02/24/2023 - 23:37:19: "Hassium<12><[U:1:238393055]><Spectator>" say_team "push cart"`,
+			match: true,
+			expected: LogEvent{
+				Type: EvtMsg, Timestamp: timeStamp, Player: "Hassium", PlayerSID: steamid.New("[U:1:238393055]"), Team: Spec,
+				Message: "push cart", TeamOnly: true,
+			},
+		},
+		{
+			text:     `02/24/2023 - 23:37:19: "Console<0><Console><Console>" say "server restarting"`,
+			match:    true,
+			expected: LogEvent{Type: EvtMsg, Timestamp: timeStamp, Player: "Console", PlayerSID: steamid.New("Console"), Team: Unassigned, Message: "server restarting"},
+		},
+		{
+			// Players cannot spoof control lines through chat.
+			text:     "02/24/2023 - 23:37:19: Hassium :  __bd_control__ kick_next",
+			match:    true,
+			expected: LogEvent{Type: EvtMsg, Timestamp: timeStamp, Player: "Hassium", Message: "__bd_control__ kick_next"},
+		},
 		{
 			// 02/26/2023 - 16:45:43: Disconnect: #TF_Idle_kicked.
 			// 02/26/2023 - 16:39:59: Connected to 169.254.174.254:26128
@@ -110,3 +180,333 @@ type tc struct {
 		}
 	}
 }
+
+func TestParseStatusHeader(t *testing.T) {
+	require.Equal(t, versionEvent{version: "7961495/24 7961495", secure: true}, parseVersion("7961495/24 7961495 secure"))
+	require.Equal(t, versionEvent{version: "7961495/24 7961495", secure: false}, parseVersion("7961495/24 7961495 insecure"))
+
+	playerCount, errCount := parsePlayerCount("14 humans, 2 bots (32 max)")
+	require.NoError(t, errCount)
+	require.Equal(t, playerCountEvent{humans: 14, bots: 2, maxPlayers: 32}, playerCount)
+
+	_, errInvalid := parsePlayerCount("lots of humans")
+	require.ErrorIs(t, errInvalid, errParsePlayerCount)
+}
+
+func TestParseLocalizedChat(t *testing.T) {
+	timeStamp := time.Date(2023, time.February, 24, 23, 37, 19, 0, time.UTC)
+	reader := newLogParser()
+
+	// The english lines are taken from testdata/console.log.
+	for text, expected := range map[string]LogEvent{
+		"02/24/2023 - 23:37:19: *DEAD*(TEAM) DexterousAVI_YT :  lol": {Player: "DexterousAVI_YT", Message: "lol", Dead: true, TeamOnly: true},
+		"02/24/2023 - 23:37:19: *DEAD* BYD3N :  f2 hes a real  guy":  {Player: "BYD3N", Message: "f2 hes a real  guy", Dead: true},
+		"02/24/2023 - 23:37:19: (TEAM) Hassium :  gg":                {Player: "Hassium", Message: "gg", TeamOnly: true},
+		"02/24/2023 - 23:37:19: *SPEC* Hassium :  gg":                {Player: "Hassium", Message: "gg", Spectator: true},
+		"02/24/2023 - 23:37:19: (Spectator) Hassium :  gg":           {Player: "Hassium", Message: "gg", Spectator: true, TeamOnly: true},
+		"02/24/2023 - 23:37:19: *COACH* Hassium :  gg":               {Player: "Hassium", Message: "gg", Coach: true},
+		"02/24/2023 - 23:37:19: (TEAM) *DEAD* lookalike :  hi":       {Player: "*DEAD* lookalike", Message: "hi", TeamOnly: true},
+	} {
+		var event LogEvent
+
+		require.NoError(t, reader.parse(text, &event))
+
+		expected.Type = EvtMsg
+		expected.Timestamp = timeStamp
+
+		require.EqualValues(t, expected, event, text)
+	}
+}
+
+// writeUTF16 writes the text the way the game ships its localization files, UTF-16LE with a byte order mark.
+func writeUTF16(t *testing.T, path string, text string) {
+	t.Helper()
+
+	body := []byte{0xff, 0xfe}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		body = append(body, byte(unit), byte(unit>>8))
+	}
+
+	require.NoError(t, os.WriteFile(path, body, 0o600))
+}
+
+func TestLoadChatPrefixes(t *testing.T) {
+	var (
+		tf2Dir      = t.TempDir()
+		resourceDir = filepath.Join(tf2Dir, "resource")
+	)
+
+	require.NoError(t, os.MkdirAll(resourceDir, 0o755))
+
+	// Only the layout of the files matters here, the "pirate" language is made up.
+	writeUTF16(t, filepath.Join(resourceDir, "tf_pirate.txt"), `"lang"
+{
+"Language" "pirate"
+"Tokens"
+{
+"TF_Chat_Team"	"(CREW) %s1 :  %s2"
+"[english]TF_Chat_Team"	"(TEAM) %s1 :  %s2"
+"TF_Chat_Team_Dead"	"*SUNK*(CREW) %s1 :  %s2"
+"TF_Chat_AllDead"	"*SUNK* %s1 :  %s2"
+"TF_Chat_All"	"%s1 :  %s2"
+}
+}
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(resourceDir, "tf_proto_obj_defs_pirate.txt"),
+		[]byte(`"lang" { "Tokens" { "TF_Quest" "quest" } }`), 0o600))
+
+	_, errNone := readChatPrefixes(filepath.Join(resourceDir, "tf_proto_obj_defs_pirate.txt"))
+	require.ErrorIs(t, errNone, errNoChatPrefixes)
+
+	languages := loadChatPrefixes(tf2Dir)
+	require.Equal(t, []localizedPrefixes{
+		englishChatPrefixes,
+		{team: "(CREW) ", dead: "*SUNK* ", deadTeam: "*SUNK*(CREW) "},
+	}, languages)
+
+	reader := newLogParser()
+	reader.loadChatPrefixes(tf2Dir)
+
+	for text, expected := range map[string]LogEvent{
+		"02/24/2023 - 23:37:19: *SUNK*(CREW) Bob :  ahoy": {Player: "Bob", Message: "ahoy", Dead: true, TeamOnly: true},
+		"02/24/2023 - 23:37:19: (CREW) Bob :  ahoy":       {Player: "Bob", Message: "ahoy", TeamOnly: true},
+		"02/24/2023 - 23:37:19: *DEAD* Bob :  ahoy":       {Player: "Bob", Message: "ahoy", Dead: true},
+	} {
+		var event LogEvent
+
+		require.NoError(t, reader.parse(text, &event))
+		require.Equal(t, expected.Player, event.Player, text)
+		require.Equal(t, expected.Dead, event.Dead, text)
+		require.Equal(t, expected.TeamOnly, event.TeamOnly, text)
+	}
+}
+
+func TestResumeLocation(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		db      = newTestDB(t, nil)
+		logPath = filepath.Join(t.TempDir(), "console.log")
+	)
+
+	// Nothing saved yet, tail from the end without replaying.
+	require.NoError(t, os.WriteFile(logPath, []byte("line 1\nline 2\n"), 0o600))
+
+	location, replayUntil := resumeLocation(ctx, db, logPath)
+	require.Equal(t, io.SeekEnd, location.Whence)
+	require.Equal(t, int64(0), replayUntil)
+
+	info, errStat := os.Stat(logPath)
+	require.NoError(t, errStat)
+
+	fileID, errFileID := platform.FileID(logPath)
+	require.NoError(t, errFileID)
+
+	checksum, errChecksum := logChecksum(logPath, 7)
+	require.NoError(t, errChecksum)
+
+	saved := store.LogOffsetSaveParams{
+		Path:       logPath,
+		FileOffset: 7,
+		FileSize:   info.Size(),
+		Inode:      int64(fileID),
+		ModTime:    info.ModTime(),
+		UpdatedOn:  time.Now(),
+		Checksum:   checksum,
+	}
+	require.NoError(t, db.LogOffsetSave(ctx, saved))
+
+	// Same file, resume from where we left off.
+	location, replayUntil = resumeLocation(ctx, db, logPath)
+	require.Equal(t, io.SeekStart, location.Whence)
+	require.Equal(t, int64(7), location.Offset)
+	require.Equal(t, info.Size(), replayUntil)
+
+	// Cleared by -conclearlog, replay the whole file.
+	for _, content := range []string{
+		"new\n",
+		// Smaller than it was, but past the saved offset.
+		"line 1\nx\n",
+		// Truncated in place and grown back past the saved size.
+		"other 1\nother 2\nother 3\n",
+	} {
+		require.NoError(t, os.WriteFile(logPath, []byte(content), 0o600))
+
+		location, replayUntil = resumeLocation(ctx, db, logPath)
+		require.Equal(t, io.SeekStart, location.Whence, content)
+		require.Equal(t, int64(0), location.Offset, content)
+		require.Equal(t, int64(len(content)), replayUntil, content)
+	}
+}
+
+func newLogPacket(packetType srcdsPacket, secret string, line string) []byte {
+	packet := []byte{0xff, 0xff, 0xff, 0xff, byte(packetType)}
+	packet = append(packet, []byte(secret+"L "+line+"\n\x00")...)
+
+	return packet
+}
+
+func TestParseLogPacket(t *testing.T) {
+	const line = "02/24/2023 - 23:37:19: hostname: Uncletopia | Seattle | 1 | All Maps"
+
+	parsed, errParse := parseLogPacket(newLogPacket(s2aLogString, "", line), 0)
+	require.NoError(t, errParse)
+	require.Equal(t, line, parsed)
+
+	parsed, errParse = parseLogPacket(newLogPacket(s2aLogString2, "1234", line), 1234)
+	require.NoError(t, errParse)
+	require.Equal(t, line, parsed)
+
+	_, errParse = parseLogPacket(newLogPacket(s2aLogString2, "4321", line), 1234)
+	require.ErrorIs(t, errParse, errLogPacketSecret)
+
+	_, errParse = parseLogPacket(newLogPacket(s2aLogString, "", line), 1234)
+	require.ErrorIs(t, errParse, errLogPacketSecret)
+
+	_, errParse = parseLogPacket([]byte("L "+line), 0)
+	require.ErrorIs(t, errParse, errLogPacketHeader)
+
+	_, errParse = parseLogPacket(newLogPacket(0x41, "", line), 0)
+	require.ErrorIs(t, errParse, errLogPacketType)
+}
+
+func TestUDPListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broadcaster := newEventBroadcaster()
+	events := make(chan LogEvent, 10)
+
+	broadcaster.registerConsumer("test", events, policyBlock, EvtAny)
+
+	listener, errListener := newUDPListener("127.0.0.1:0", 1234, newLogParser(), broadcaster)
+	require.NoError(t, errListener)
+
+	conn, errListen := net.ListenUDP("udp4", listener.udpAddr)
+	require.NoError(t, errListen)
+
+	go listener.serve(ctx, conn)
+
+	sender, errDial := net.DialUDP("udp4", nil, conn.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, errDial)
+
+	defer IgnoreClose(sender)
+
+	// Packets with the wrong secret are discarded, so the first event received must be from the valid packet.
+	_, errWrite := sender.Write(newLogPacket(s2aLogString2, "4321", "02/24/2023 - 23:37:19: hostname: wrong secret"))
+	require.NoError(t, errWrite)
+
+	_, errWrite = sender.Write(newLogPacket(s2aLogString2, "1234", "02/24/2023 - 23:37:19: hostname: Uncletopia | Seattle | 1 | All Maps"))
+	require.NoError(t, errWrite)
+
+	select {
+	case evt := <-events:
+		require.Equal(t, EventType(EvtHostname), evt.Type)
+		require.Equal(t, "Uncletopia | Seattle | 1 | All Maps", evt.MetaData)
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out waiting for log event")
+	}
+
+	_, errWrite = sender.Write(newLogPacket(s2aLogString2, "1234", `02/24/2023 - 23:37:19: "Hassium<12><[U:1:238393055]><Red>" say_team "gg"`))
+	require.NoError(t, errWrite)
+
+	select {
+	case evt := <-events:
+		require.Equal(t, EventType(EvtMsg), evt.Type)
+		require.Equal(t, steamid.New("[U:1:238393055]"), evt.PlayerSID)
+		require.True(t, evt.TeamOnly)
+		require.Equal(t, "gg", evt.Message)
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out waiting for log event")
+	}
+}
+
+func TestEventBroadcaster(t *testing.T) {
+	broadcaster := newEventBroadcaster()
+
+	dropping := make(chan LogEvent, 2)
+	unregisterDropping := broadcaster.registerConsumer("dropping", dropping, policyDropOldest, EvtMsg)
+
+	blocking := make(chan LogEvent, 1)
+	unregisterBlocking := broadcaster.registerConsumer("blocking", blocking, policyBlock, EvtAny)
+
+	// Unregister the blocking consumer once its full, otherwise the next broadcast would never return.
+	broadcaster.broadcast(LogEvent{Type: EvtMsg, Message: "1"})
+	unregisterBlocking()
+
+	for _, msg := range []string{"2", "3", "4"} {
+		broadcaster.broadcast(LogEvent{Type: EvtMsg, Message: msg})
+	}
+
+	// Only matching event types are delivered.
+	broadcaster.broadcast(LogEvent{Type: EvtKill})
+
+	require.Equal(t, "3", (<-dropping).Message)
+	require.Equal(t, "4", (<-dropping).Message)
+	require.Len(t, blocking, 1)
+
+	stats := broadcaster.consumerStats()
+	require.Len(t, stats, 1)
+	require.Equal(t, ConsumerStats{Name: "dropping", Policy: "drop_oldest", Capacity: 2, Dropped: 2}, stats[0])
+
+	unregisterDropping()
+	unregisterDropping()
+
+	broadcaster.broadcast(LogEvent{Type: EvtMsg, Message: "5"})
+	require.Empty(t, dropping)
+	require.Empty(t, broadcaster.consumerStats())
+}
+
+func TestReplayOverflow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		state, _    = newTestState(t, map[string]any{"event_journal_enabled": true, "event_journal_retention": 0})
+		db          = state.db
+		broadcaster = newEventBroadcaster()
+		journal     = newEventJournal(db, state, broadcaster)
+		logPath     = filepath.Join(t.TempDir(), "console.log")
+		lines       = eventQueueSize + 44
+		content     strings.Builder
+	)
+
+	for index := range lines {
+		content.WriteString(fmt.Sprintf("02/24/2023 - 23:37:19: player :  message %d\n", index))
+	}
+
+	require.NoError(t, os.WriteFile(logPath, []byte(content.String()), 0o600))
+
+	info, errStat := os.Stat(logPath)
+	require.NoError(t, errStat)
+
+	// Everything was written after the last saved offset, so it is all replayed.
+	require.NoError(t, db.LogOffsetSave(ctx, store.LogOffsetSaveParams{
+		Path:      logPath,
+		ModTime:   info.ModTime(),
+		UpdatedOn: time.Now(),
+	}))
+
+	ingest, errIngest := newLogIngest(ctx, logPath, newLogParser(), false, broadcaster, db)
+	require.NoError(t, errIngest)
+
+	go ingest.start(ctx)
+
+	// Fill the journal queue before it starts reading, the rest of the replay must wait for room.
+	require.Eventually(t, func() bool {
+		return len(journal.incoming) == eventQueueSize
+	}, time.Second*5, time.Millisecond*10)
+
+	go journal.start(ctx)
+
+	require.Eventually(t, func() bool {
+		events, errEvents := db.Events(ctx, store.EventsParams{
+			StartTime: time.Time{}, EndTime: time.Now(), EventType: EventType(EvtMsg).String(), SteamID: 0,
+		})
+
+		return errEvents == nil && len(events) == lines
+	}, time.Second*5, time.Millisecond*10)
+
+	for _, stats := range broadcaster.consumerStats() {
+		require.Zero(t, stats.Dropped, stats.Name)
+	}
+}
@@ -167,26 +167,40 @@ func run() int {
 	logCloser := MustCreateLogger(settings)
 	defer logCloser()
 
-	rcon := newRconConnection(settings.Rcon.String(), settings.Rcon.Password)
-	state := newGameState(db, settingsMgr, newPlayerStates(), rcon, db)
+	re := createRulesEngine(settings)
+	rcon := newRconScheduler(newRconConnection(settings.Rcon.String(), settings.Rcon.Password))
+	state := newGameState(db, settingsMgr, newPlayerStates(), rcon, db, re)
 	parser := newLogParser()
+	parser.loadChatPrefixes(settings.Tf2Dir)
 	broadcaster := newEventBroadcaster()
 
 	var logSrc backgroundService
 
-	ingest, errLogReader := newLogIngest(filepath.Join(settings.Tf2Dir, "console.log"), parser, true, broadcaster)
-	if errLogReader != nil {
-		slog.Error("Failed to create log startEventEmitter", errAttr(errLogReader))
-		return 1
-	}
+	switch settings.LogSource {
+	case LogSourceUDP:
+		listener, errListener := newUDPListener(settings.UdpListenAddr, settings.UdpLogSecret, parser, broadcaster)
+		if errListener != nil {
+			slog.Error("Failed to create udp log listener", errAttr(errListener))
+			return 1
+		}
 
-	go testLogFeeder(ctx, ingest)
+		logSrc = listener
+	default:
+		ingest, errLogReader := newLogIngest(ctx, filepath.Join(settings.Tf2Dir, "console.log"), parser, true, broadcaster, db)
+		if errLogReader != nil {
+			slog.Error("Failed to create log startEventEmitter", errAttr(errLogReader))
+			return 1
+		}
 
-	logSrc = ingest
+		go testLogFeeder(ctx, ingest)
+
+		logSrc = ingest
+	}
 
 	chat := newChatRecorder(db, broadcaster)
+	journal := newEventJournal(db, state, broadcaster)
 
-	broadcaster.registerConsumer(state.eventChan, EvtAny)
+	broadcaster.registerConsumer("state", state.eventChan, policyBlock, EvtAny)
 
 	dataSource, errDataSource := newDataSource(settings)
 	if errDataSource != nil {
@@ -194,8 +208,6 @@ func run() int {
 		return 1
 	}
 
-	re := createRulesEngine(settings)
-
 	cache, cacheErr := NewCache(configRoot, DurationCacheTimeout)
 	if cacheErr != nil {
 		slog.Error("Failed to set up cache", errAttr(cacheErr))
@@ -208,8 +220,10 @@ func run() int {
 	processHandler := newProcessState(plat, rcon, settingsMgr)
 	statusHandler := newStatusUpdater(rcon, processHandler, state, time.Second*2)
 	bigBrotherHandler := newOverwatch(settingsMgr, rcon, state)
+	bigBrotherHandler.peers.register(broadcaster)
+	commander := newChatCommander(settingsMgr, db, state, re, &bigBrotherHandler, broadcaster)
 
-	mux, errRoutes := createHandlers(ctx, db, state, processHandler, settingsMgr, re, rcon)
+	mux, errRoutes := createHandlers(ctx, db, state, processHandler, settingsMgr, re, bigBrotherHandler.queue, broadcaster, rcon)
 	if errRoutes != nil {
 		slog.Error("failed to create http handlers", errAttr(errRoutes))
 
@@ -219,7 +233,10 @@ func run() int {
 	httpServer := newHTTPServer(ctx, settings.HttpListenAddr, mux)
 
 	// Start all the background workers
-	for _, svc := range []backgroundService{discordPresence, chat, logSrc, updater, statusHandler, &bigBrotherHandler, processHandler, state} {
+	for _, svc := range []backgroundService{
+		discordPresence, chat, journal, logSrc, updater, statusHandler, &bigBrotherHandler, processHandler, state, rcon,
+		commander, bigBrotherHandler.peers,
+	} {
 		go svc.start(ctx)
 	}
 
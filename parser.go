@@ -9,6 +9,7 @@
 	"strings"
 	"time"
 
+	"github.com/leighmacdonald/bd/addons"
 	"github.com/leighmacdonald/steamid/v4/steamid"
 )
 
@@ -26,6 +27,17 @@ type tagsEvent struct {
 	tags []string
 }
 
+type versionEvent struct {
+	version string
+	secure  bool
+}
+
+type playerCountEvent struct {
+	humans     int
+	bots       int
+	maxPlayers int
+}
+
 const logTimestampFormat = "01/02/2006 - 15:04:05"
 
 // parseTimestamp will convert the source formatted log timestamps into a time.Time value.
@@ -48,11 +60,18 @@ type LogEvent struct {
 	PlayerSID       steamid.SteamID
 	Victim          string
 	VictimSID       steamid.SteamID
+	Weapon          string
+	Crit            bool
 	Message         string
 	Timestamp       time.Time
 	MetaData        string
 	Dead            bool
 	TeamOnly        bool
+	Spectator       bool
+	Coach           bool
+	// Replayed is set for events read from the log that were written before we started. These should be
+	// recorded, but never acted upon.
+	Replayed bool
 }
 
 func (e *LogEvent) ApplyTimestamp(tsString string) error {
@@ -113,6 +132,8 @@ func (ut updateType) String() string {
 type killEvent struct {
 	sourceName string
 	victimName string
+	weapon     string
+	crit       bool
 }
 
 type statusEvent struct {
@@ -146,17 +167,21 @@ type logParser struct {
 	ReadChannel chan string
 	rx          []*regexp.Regexp
 	logger      *slog.Logger
+	prefixes    []chatPrefix
+	// srcdsKill and srcdsSay match the srcds log format received by the udpListener, which quotes the players
+	// along with their userid, steam id and team, eg: `"name<12><[U:1:123]><Red>"`.
+	srcdsKill *regexp.Regexp
+	srcdsSay  *regexp.Regexp
 }
 
-const (
-	teamPrefix     = "(TEAM) "
-	deadPrefix     = "*DEAD* "
-	deadTeamPrefix = "*DEAD*(TEAM) "
-	// coachPrefix    = "*COACH* ".
-)
+const critSuffix = ". (crit)"
 
 func newLogParser() *logParser {
 	return &logParser{
+		logger:    slog.Default().WithGroup("parser"),
+		prefixes:  newChatPrefixes([]localizedPrefixes{englishChatPrefixes}),
+		srcdsKill: regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\s"(?P<name>.+?)<\d+><(?P<sid>[^>]*)><(?P<team>[^>]*)>"\skilled\s"(?P<victim>.+?)<\d+><(?P<victim_sid>[^>]*)><[^>]*>"\swith\s"(?P<weapon>[^"]*)"(?P<props>.*)$`),
+		srcdsSay:  regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\s"(?P<name>.+?)<\d+><(?P<sid>[^>]*)><(?P<team>[^>]*)>"\s(?P<kind>say|say_team)\s"(?P<message>.*)"$`),
 		rx: []*regexp.Regexp{
 			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\s(.+?)\skilled\s(.+?)\swith\s(.+)(\.|\. \(crit\))$`),
 			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\s(?P<name>.+?)\s:\s{2}(?P<message>.+?)$`),
@@ -168,11 +193,28 @@ func newLogParser() *logParser {
 			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\stags\s{4}:\s(.+?)$`),
 			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\sudp/ip\s{2}:\s(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}:\d{1,5})$`),
 			regexp.MustCompile(`^\s{2}(Member|Pending)\[\d+]\s+(?P<sid>\[.+?]).+?TF_GC_TEAM_(?P<team>(DEFENDERS|INVADERS))\s{2}type\s=\sMATCH_PLAYER$`),
+			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\sversion\s:\s(.+?)$`),
+			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\splayers\s:\s(\d+\shumans,\s\d+\sbots\s\(\d+\smax\))$`),
+			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\s` + addons.BindMarker + `\s(?P<action>[a-z_]+)$`),
+			// Only sent in srcds logs, the client console does not show round results.
+			regexp.MustCompile(`^(?P<dt>[01]\d/[0123]\d/20\d{2}\s-\s\d{2}:\d{2}:\d{2}):\sWorld triggered "Round_Win" \(winner "(?P<team>Red|Blue)"\)`),
 		},
 	}
 }
 
+// loadChatPrefixes replaces the english chat prefixes with those of every language installed with the game. It
+// must be called before the parser is used.
+func (parser *logParser) loadChatPrefixes(tf2Dir string) {
+	parser.prefixes = newChatPrefixes(loadChatPrefixes(tf2Dir))
+}
+
 func (parser *logParser) parse(msg string, outEvent *LogEvent) error {
+	// The srcds lines are checked first as the quoted names and messages could otherwise be mistaken for the
+	// client formatted chat and kill lines.
+	if parser.parseSrcds(msg, outEvent) {
+		return nil
+	}
+
 	// the index must match the index of the EventType const values
 	for i, rxMatcher := range parser.rx {
 		if match := rxMatcher.FindStringSubmatch(msg); match != nil { //nolint:nestif
@@ -189,26 +231,12 @@ func (parser *logParser) parse(msg string, outEvent *LogEvent) error {
 			case EvtDisconnect:
 				outEvent.MetaData = match[2]
 			case EvtMsg:
-				name := match[2]
-				dead := false
-				team := false
-
-				if strings.HasPrefix(name, teamPrefix) {
-					name = strings.TrimPrefix(name, teamPrefix)
-					team = true
-				}
-
-				if strings.HasPrefix(name, deadTeamPrefix) {
-					name = strings.TrimPrefix(name, deadTeamPrefix)
-					dead = true
-					team = true
-				} else if strings.HasPrefix(name, deadPrefix) {
-					dead = true
-					name = strings.TrimPrefix(name, deadPrefix)
-				}
+				name, prefix := trimChatPrefix(parser.prefixes, match[2])
 
-				outEvent.TeamOnly = team
-				outEvent.Dead = dead
+				outEvent.TeamOnly = prefix.team
+				outEvent.Dead = prefix.dead
+				outEvent.Spectator = prefix.spectator
+				outEvent.Coach = prefix.coach
 				outEvent.Player = name
 				outEvent.Message = match[3]
 			case EvtStatusID:
@@ -241,6 +269,8 @@ func (parser *logParser) parse(msg string, outEvent *LogEvent) error {
 			case EvtKill:
 				outEvent.Player = match[2]
 				outEvent.Victim = match[3]
+				outEvent.Weapon = match[4]
+				outEvent.Crit = match[5] == critSuffix
 			case EvtHostname:
 				outEvent.MetaData = match[2]
 			case EvtMap:
@@ -249,6 +279,18 @@ func (parser *logParser) parse(msg string, outEvent *LogEvent) error {
 				outEvent.MetaData = match[2]
 			case EvtAddress:
 				outEvent.MetaData = match[2]
+			case EvtVersion:
+				outEvent.MetaData = match[2]
+			case EvtPlayerCount:
+				outEvent.MetaData = match[2]
+			case EvtControl:
+				outEvent.MetaData = match[2]
+			case EvtRoundWin:
+				if match[2] == "Blue" {
+					outEvent.Team = Blu
+				} else {
+					outEvent.Team = Red
+				}
 			case EvtLobby:
 				outEvent.PlayerSID = steamid.New(match[2])
 				if match[3] == "INVADERS" {
@@ -265,6 +307,80 @@ func (parser *logParser) parse(msg string, outEvent *LogEvent) error {
 	return ErrNoMatch
 }
 
+// parseSrcds handles the kill and chat lines of the srcds log format, eg:
+//
+//	02/24/2023 - 23:37:19: "Hassium<12><[U:1:123]><Red>" killed "Nosy<13><[U:1:456]><Blue>" with "scattergun" (crit "crit") (attacker_position "1 2 3") (victim_position "4 5 6")
+//	02/24/2023 - 23:37:19: "Hassium<12><[U:1:123]><Red>" say_team "gg"
+//
+// Bots and the server console have no steam id, leaving PlayerSID invalid.
+func (parser *logParser) parseSrcds(msg string, outEvent *LogEvent) bool {
+	var match []string
+
+	if match = parser.srcdsKill.FindStringSubmatch(msg); match != nil {
+		outEvent.Type = EvtKill
+		outEvent.Victim = match[5]
+		outEvent.VictimSID = steamid.New(match[6])
+		outEvent.Weapon = match[7]
+		outEvent.Crit = strings.Contains(match[8], `(crit "crit")`)
+	} else if match = parser.srcdsSay.FindStringSubmatch(msg); match != nil {
+		outEvent.Type = EvtMsg
+		outEvent.TeamOnly = match[5] == "say_team"
+		outEvent.Message = match[6]
+	} else {
+		return false
+	}
+
+	outEvent.Player = match[2]
+	outEvent.PlayerSID = steamid.New(match[3])
+	outEvent.Team = srcdsTeam(match[4])
+
+	if errTS := outEvent.ApplyTimestamp(match[1]); errTS != nil {
+		parser.logger.Error("Failed to parse timestamp", errAttr(errTS))
+	}
+
+	return true
+}
+
+// srcdsTeam converts the team names used in the srcds logs.
+func srcdsTeam(name string) Team {
+	switch name {
+	case "Red":
+		return Red
+	case "Blue":
+		return Blu
+	case "Spectator":
+		return Spec
+	default:
+		return Unassigned
+	}
+}
+
+// parseVersion splits the status version line value, eg: `7961495/24 7961495 secure`.
+func parseVersion(value string) versionEvent {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return versionEvent{}
+	}
+
+	last := fields[len(fields)-1]
+	if last != "secure" && last != "insecure" {
+		return versionEvent{version: strings.Join(fields, " ")}
+	}
+
+	return versionEvent{version: strings.Join(fields[:len(fields)-1], " "), secure: last == "secure"}
+}
+
+// parsePlayerCount parses the status players line value, eg: `14 humans, 0 bots (32 max)`.
+func parsePlayerCount(value string) (playerCountEvent, error) {
+	var evt playerCountEvent
+
+	if _, errScan := fmt.Sscanf(value, "%d humans, %d bots (%d max)", &evt.humans, &evt.bots, &evt.maxPlayers); errScan != nil {
+		return evt, errors.Join(errScan, errParsePlayerCount)
+	}
+
+	return evt, nil
+}
+
 func parseConnected(d string) (time.Duration, error) {
 	var (
 		pcs      = strings.Split(d, ":")
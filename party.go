@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+const (
+	partyUpdateInterval = time.Second * 10
+	// partyConnectWindow is how close together the connect times of two players must be to count as connecting
+	// together.
+	partyConnectWindow = time.Second * 5
+	// partyMinSessions is the number of previous sessions two players must have shared.
+	partyMinSessions = 2
+	// partyLinkScore is the score required to link two players. Friendship alone is enough, the weaker
+	// signals need to be seen together.
+	partyLinkScore = 2
+)
+
+// Reasons two players were linked together. The game does not expose which players queued as a party, the
+// tf_lobby_debug output only lists the lobby members and their teams, so partyReasonConnected is based on the
+// connect time from the status output instead.
+const (
+	partyReasonFriends   = "friends"
+	partyReasonSessions  = "sessions"
+	partyReasonConnected = "connected_together"
+)
+
+// Party is a group of players on the same team that are likely to have queued together.
+type Party struct {
+	PartyID int                `json:"party_id"`
+	Team    Team               `json:"team"`
+	Members steamid.Collection `json:"members"`
+	Reasons []string           `json:"reasons"`
+}
+
+// partyLinks is the stored data about a player used to link them to others. It only changes between sessions
+// so it is loaded once per player and session.
+type partyLinks struct {
+	friends    map[steamid.SteamID]bool
+	coSessions map[steamid.SteamID]int64
+}
+
+// partyTracker holds the cached link data. It is only accessed by the status updater.
+type partyTracker struct {
+	updatedLast time.Time
+	sessionID   int64
+	links       map[steamid.SteamID]partyLinks
+}
+
+// linkScore rates how likely it is that the two players queued together.
+func linkScore(playerA PlayerState, playerB PlayerState, links map[steamid.SteamID]partyLinks) (int, []string) {
+	var (
+		score   int
+		reasons []string
+		linksA  = links[playerA.SteamID]
+		linksB  = links[playerB.SteamID]
+	)
+
+	// Friends lists are not always visible, so one side is enough.
+	if linksA.friends[playerB.SteamID] || linksB.friends[playerA.SteamID] {
+		score += 2
+		reasons = append(reasons, partyReasonFriends)
+	}
+
+	if max(linksA.coSessions[playerB.SteamID], linksB.coSessions[playerA.SteamID]) >= partyMinSessions {
+		score++
+		reasons = append(reasons, partyReasonSessions)
+	}
+
+	if playerA.Connected > 0 && playerB.Connected > 0 {
+		connectedA := playerA.UpdatedOn.Add(-playerA.Connected)
+		connectedB := playerB.UpdatedOn.Add(-playerB.Connected)
+
+		if connectedA.Sub(connectedB).Abs() <= partyConnectWindow {
+			score++
+			reasons = append(reasons, partyReasonConnected)
+		}
+	}
+
+	return score, reasons
+}
+
+// findParties groups the connected players on each team that are linked to each other, directly or through
+// another member.
+func findParties(players []PlayerState, links map[steamid.SteamID]partyLinks) []Party {
+	var (
+		parent  = make([]int, len(players))
+		reasons = map[int][]string{}
+	)
+
+	for index := range parent {
+		parent[index] = index
+	}
+
+	var root func(index int) int
+	root = func(index int) int {
+		if parent[index] != index {
+			parent[index] = root(parent[index])
+		}
+
+		return parent[index]
+	}
+
+	for indexA := range players {
+		for indexB := indexA + 1; indexB < len(players); indexB++ {
+			if players[indexA].Team != players[indexB].Team {
+				continue
+			}
+
+			score, linkReasons := linkScore(players[indexA], players[indexB], links)
+			if score < partyLinkScore {
+				continue
+			}
+
+			rootA, rootB := root(indexA), root(indexB)
+			parent[rootB] = rootA
+			reasons[rootA] = append(append(reasons[rootA], reasons[rootB]...), linkReasons...)
+		}
+	}
+
+	var (
+		parties []Party
+		byRoot  = map[int]int{}
+	)
+
+	for index, player := range players {
+		rootIndex := root(index)
+		if rootIndex == index && len(reasons[rootIndex]) == 0 {
+			// Not linked to anyone
+			continue
+		}
+
+		partyIndex, found := byRoot[rootIndex]
+		if !found {
+			slices.Sort(reasons[rootIndex])
+
+			parties = append(parties, Party{
+				PartyID: len(parties) + 1,
+				Team:    player.Team,
+				Reasons: slices.Compact(reasons[rootIndex]),
+			})
+			partyIndex = len(parties) - 1
+			byRoot[rootIndex] = partyIndex
+		}
+
+		parties[partyIndex].Members = append(parties[partyIndex].Members, player.SteamID)
+	}
+
+	return parties
+}
+
+// hasRuleMatch checks for any match besides the ones we derive from other players.
+func hasRuleMatch(player PlayerState) bool {
+	return slices.ContainsFunc(player.Matches, func(match rules.MatchResult) bool {
+		return match.MatcherType != friendOfMarkedMatcher
+	}) && !player.Whitelist
+}
+
+// updateParties recalculates the parties among the connected players. Players that are in a party with a matched
+// player are flagged as associated with them, but are not marked themselves.
+func (s *gameState) updateParties(ctx context.Context) {
+	if time.Since(s.party.updatedLast) < partyUpdateInterval {
+		return
+	}
+
+	s.party.updatedLast = time.Now()
+
+	s.mu.RLock()
+	sessionID := s.session.SessionID
+	s.mu.RUnlock()
+
+	if s.party.links == nil || s.party.sessionID != sessionID {
+		s.party.links = map[steamid.SteamID]partyLinks{}
+		s.party.sessionID = sessionID
+	}
+
+	var connected []PlayerState
+
+	for _, player := range s.players.all() {
+		if !player.IsConnected || (player.Team != Red && player.Team != Blu) {
+			if player.PartyID != 0 || len(player.AssociatedWith) > 0 {
+				player.PartyID = 0
+				player.AssociatedWith = nil
+
+				s.players.update(player)
+			}
+
+			continue
+		}
+
+		if _, found := s.party.links[player.SteamID]; !found {
+			s.party.links[player.SteamID] = s.loadPartyLinks(ctx, player.SteamID, sessionID)
+		}
+
+		connected = append(connected, player)
+	}
+
+	parties := findParties(connected, s.party.links)
+	partyOf := map[steamid.SteamID]Party{}
+
+	for _, party := range parties {
+		for _, member := range party.Members {
+			partyOf[member] = party
+		}
+	}
+
+	for _, player := range connected {
+		party := partyOf[player.SteamID]
+
+		var associated steamid.Collection
+
+		for _, member := range party.Members {
+			if member == player.SteamID {
+				continue
+			}
+
+			if memberState, errMember := s.players.bySteamID(member); errMember == nil && hasRuleMatch(memberState) {
+				associated = append(associated, member)
+			}
+		}
+
+		current, errCurrent := s.players.bySteamID(player.SteamID)
+		if errCurrent != nil {
+			continue
+		}
+
+		current.PartyID = party.PartyID
+		current.AssociatedWith = associated
+
+		s.players.update(current)
+	}
+
+	s.mu.Lock()
+	s.parties = parties
+	s.mu.Unlock()
+}
+
+func (s *gameState) loadPartyLinks(ctx context.Context, steamID steamid.SteamID, sessionID int64) partyLinks {
+	links := partyLinks{friends: map[steamid.SteamID]bool{}, coSessions: map[steamid.SteamID]int64{}}
+
+	friends, errFriends := s.db.Friends(ctx, steamID.Int64())
+	if errFriends != nil {
+		slog.Error("Failed to load friends", sidAttr(steamID), errAttr(errFriends))
+	}
+
+	for _, friend := range friends {
+		links.friends[steamid.New(friend.SteamIDFriend)] = true
+	}
+
+	coPlayers, errCoPlayers := s.db.SessionCoPlayers(ctx, store.SessionCoPlayersParams{
+		SteamID:     steamID.Int64(),
+		SessionID:   sessionID,
+		MinSessions: partyMinSessions,
+	})
+	if errCoPlayers != nil {
+		slog.Error("Failed to load co players", sidAttr(steamID), errAttr(errCoPlayers))
+	}
+
+	for _, coPlayer := range coPlayers {
+		links.coSessions[steamid.New(coPlayer.SteamID)] = coPlayer.Sessions
+	}
+
+	return links
+}
+
+// Parties returns the parties found among the current players.
+func (s *gameState) Parties() []Party {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.parties)
+}
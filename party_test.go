@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindParties(t *testing.T) {
+	var (
+		now    = time.Now()
+		first  = steamid.New(76561197961279983)
+		second = steamid.New(76561198084134025)
+		third  = steamid.New(76561197970669109)
+		fourth = steamid.New(76561197992870439)
+		fifth  = steamid.New(76561198057999536)
+	)
+
+	players := []PlayerState{
+		{SteamID: first, Team: Red, UpdatedOn: now, Connected: time.Minute * 10},
+		{SteamID: second, Team: Red, UpdatedOn: now, Connected: time.Minute*10 + time.Second*2},
+		{SteamID: third, Team: Red, UpdatedOn: now, Connected: time.Minute * 30},
+		// Friends, but on the other team.
+		{SteamID: fourth, Team: Blu, UpdatedOn: now, Connected: time.Minute * 30},
+		{SteamID: fifth, Team: Blu, UpdatedOn: now, Connected: time.Minute * 2},
+	}
+
+	links := map[steamid.SteamID]partyLinks{
+		// Connected together and played together before.
+		first: {coSessions: map[steamid.SteamID]int64{second: 3}},
+		// Linked to the first through the second.
+		third: {friends: map[steamid.SteamID]bool{second: true, fourth: true}},
+	}
+
+	parties := findParties(players, links)
+	require.Len(t, parties, 1)
+	require.Equal(t, steamid.Collection{first, second, third}, parties[0].Members)
+	require.Equal(t, Red, parties[0].Team)
+	require.Equal(t, []string{partyReasonConnected, partyReasonFriends, partyReasonSessions}, parties[0].Reasons)
+
+	// Connecting at the same time alone is not enough.
+	players[4].Connected = players[3].Connected
+	require.Len(t, findParties(players[3:], nil), 0)
+}
+
+func TestLoadPartyLinks(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		state, _ = newTestState(t, nil)
+		player   = PlayerState{SteamID: testUs, Personaname: "player"}
+		often    = PlayerState{SteamID: steamid.New(76561198084134025), Personaname: "often"}
+		once     = PlayerState{SteamID: steamid.New(76561197970669109), Personaname: "once"}
+	)
+
+	state.onHostname(hostnameEvent{hostname: "Uncletopia | Seattle | 1"})
+	state.server.Addr = net.ParseIP("1.2.3.4")
+	state.server.Port = 27015
+
+	for index, mapName := range []string{"pl_badwater", "pl_upward", "pl_swiftwater_final1"} {
+		state.onMapName(mapEvent{mapName: mapName})
+		state.syncSession(ctx)
+		require.NotEqual(t, int64(0), state.session.SessionID)
+
+		state.addSessionPlayer(ctx, player)
+		state.addSessionPlayer(ctx, often)
+
+		if index == 0 {
+			state.addSessionPlayer(ctx, once)
+		}
+	}
+
+	// The current session does not count towards the shared sessions.
+	links := state.loadPartyLinks(ctx, player.SteamID, state.session.SessionID)
+	require.Equal(t, map[steamid.SteamID]int64{often.SteamID: partyMinSessions}, links.coSessions)
+}
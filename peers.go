@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+const (
+	// peerTag is appended to our announcements so other bd instances can tell they are not alone.
+	peerTag = "[bd]"
+	// peerTimeout is how long a peer is considered active after we last saw one of their tagged messages.
+	peerTimeout = DurationAnnounceMatchTimeout * 2
+	// peerClaimTimeout is how long we skip a target after a peer tells us they called a vote against them.
+	peerClaimTimeout = voteFailureCooldown
+)
+
+// rePeerTag matches the tag at the end of a chat message, eg: `[bd]` or a kick claim `[bd:kick=12]`.
+var rePeerTag = regexp.MustCompile(`\[bd(?::kick=(\d+))?]$`)
+
+// peerKickClaim formats the message sent after calling a vote, so peers skip the same target.
+func peerKickClaim(userID int) string {
+	return fmt.Sprintf("[bd:kick=%d]", userID)
+}
+
+// parsePeerTag checks the message for a peer tag. The user id of the kick target is returned for kick claims,
+// otherwise it is 0.
+func parsePeerTag(message string) (int, bool) {
+	match := rePeerTag.FindStringSubmatch(message)
+	if match == nil {
+		return 0, false
+	}
+
+	if match[1] == "" {
+		return 0, true
+	}
+
+	userID, errUserID := strconv.Atoi(match[1])
+	if errUserID != nil {
+		return 0, false
+	}
+
+	return userID, true
+}
+
+type peer struct {
+	steamID steamid.SteamID
+	// announcedLast is the last time the peer announced one of the players we have matched. Peers that only send
+	// kick claims, or tagged messages that announce nobody, never take over our announcements.
+	announcedLast time.Time
+	seenLast      time.Time
+}
+
+// peerTracker detects other bd instances on the same server from the tags on their chat messages, so the work
+// can be split between us. Only one instance per team announces matches, the one with the lowest steam id, and
+// targets another instance has called a vote against are skipped.
+//
+// Anyone can type a tag into chat, so a peer is only trusted with our announcements and our own targets once
+// we have seen them announce one of the players we have matched ourselves.
+type peerTracker struct {
+	incoming chan LogEvent
+	settings configManager
+	state    *gameState
+	queue    *kickQueue
+	mu       *sync.RWMutex
+	peers    map[steamid.SteamID]peer
+	// claims maps the user id of kick targets to the time the claim expires.
+	claims map[int]time.Time
+}
+
+func newPeerTracker(settings configManager, state *gameState, queue *kickQueue) *peerTracker {
+	return &peerTracker{
+		incoming: make(chan LogEvent, eventQueueSize),
+		settings: settings,
+		state:    state,
+		queue:    queue,
+		mu:       &sync.RWMutex{},
+		peers:    map[steamid.SteamID]peer{},
+		claims:   map[int]time.Time{},
+	}
+}
+
+func (p *peerTracker) register(broadcaster *eventBroadcaster) {
+	broadcaster.registerConsumer("peers", p.incoming, policyDropOldest, EvtMsg)
+}
+
+func (p *peerTracker) start(ctx context.Context) {
+	for {
+		select {
+		case evt := <-p.incoming:
+			if evt.Replayed {
+				continue
+			}
+
+			p.onMessage(ctx, evt)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *peerTracker) onMessage(ctx context.Context, evt LogEvent) {
+	claim, tagged := parsePeerTag(evt.Message)
+	if !tagged {
+		return
+	}
+
+	sender, errSender := p.state.players.byUniqueName(evt.Player)
+	if errSender != nil {
+		return
+	}
+
+	settings, errSettings := p.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to load settings", errAttr(errSettings))
+
+		return
+	}
+
+	if sender.SteamID == settings.GetSteamID() || !p.trusted(sender, claim) {
+		return
+	}
+
+	now := time.Now()
+
+	if claim == 0 {
+		p.seen(sender.SteamID, p.announcesMatch(evt.Message), now)
+
+		return
+	}
+
+	p.seen(sender.SteamID, false, now)
+
+	if p.claimAllowed(sender.SteamID, claim, now) {
+		p.claim(claim, now)
+	}
+}
+
+// trusted checks if a tagged message from the sender should be honoured at all. Players we would kick are
+// ignored, otherwise they could silence our announcements or hold off our votes against themselves.
+func (p *peerTracker) trusted(sender PlayerState, claim int) bool {
+	if len(sender.Matches) > 0 || p.queue.contains(sender.SteamID) {
+		return false
+	}
+
+	return claim != sender.UserID
+}
+
+// announcesMatch checks if the message names one of the connected players we have matched, which is what the
+// announcements of a real peer look like. Names are compared as they would be sent in chat.
+func (p *peerTracker) announcesMatch(message string) bool {
+	for _, player := range p.state.players.current() {
+		if !player.IsConnected || len(player.Matches) == 0 || player.Personaname == "" {
+			continue
+		}
+
+		if strings.Contains(message, strings.TrimSpace(chatUnsafe.Replace(player.Personaname))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// claimAllowed checks if a claim against the target should hold off our own votes. Claims never apply to the
+// players we queued, and only peers we have seen announcing can claim the players we have matched.
+func (p *peerTracker) claimAllowed(steamID steamid.SteamID, claim int, now time.Time) bool {
+	target, errTarget := p.state.players.byUserID(claim)
+	if errTarget != nil {
+		return true
+	}
+
+	if p.queue.contains(target.SteamID) {
+		return false
+	}
+
+	if len(target.Matches) == 0 {
+		return true
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return now.Sub(p.peers[steamID].announcedLast) < peerTimeout
+}
+
+// seen records a tagged message from the peer.
+func (p *peerTracker) seen(steamID steamid.SteamID, announced bool, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	known, found := p.peers[steamID]
+	if !found {
+		slog.Info("Detected another bd instance", sidAttr(steamID))
+
+		known.steamID = steamID
+	}
+
+	known.seenLast = now
+
+	if announced {
+		known.announcedLast = now
+	}
+
+	p.peers[steamID] = known
+}
+
+// claim holds off our votes against the target until the claim expires.
+func (p *peerTracker) claim(userID int, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.claims[userID] = now.Add(peerClaimTimeout)
+}
+
+// active returns true if any peers have been seen recently.
+func (p *peerTracker) active(now time.Time) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, known := range p.peers {
+		if now.Sub(known.seenLast) < peerTimeout {
+			return true
+		}
+	}
+
+	return false
+}
+
+// claimed returns true if a peer has called a vote against the player recently.
+func (p *peerTracker) claimed(userID int, now time.Time) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	expires, found := p.claims[userID]
+
+	return found && now.Before(expires)
+}
+
+// isAnnouncer checks if we should be the one announcing to our team. Another instance on our team that is
+// announcing takes over when they have a lower steam id.
+func (p *peerTracker) isAnnouncer(ourSteamID steamid.SteamID, ourTeam Team, now time.Time) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, known := range p.peers {
+		if now.Sub(known.announcedLast) >= peerTimeout || known.steamID.Int64() > ourSteamID.Int64() {
+			continue
+		}
+
+		player, errPlayer := p.state.players.bySteamID(known.steamID)
+		if errPlayer == nil && player.IsConnected && player.Team == ourTeam {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeerTag(t *testing.T) {
+	_, tagged := parsePeerTag("gg")
+	require.False(t, tagged)
+
+	claim, tagged := parsePeerTag("bot is a cheater [bd]")
+	require.True(t, tagged)
+	require.Zero(t, claim)
+
+	claim, tagged = parsePeerTag(peerKickClaim(12))
+	require.True(t, tagged)
+	require.Equal(t, 12, claim)
+}
+
+func TestPeerCoordination(t *testing.T) {
+	ctx := context.Background()
+	watch, rcon := newTestOverwatch(t, true)
+
+	var (
+		now   = time.Now()
+		lower = testKickTarget(76561197960265728, 8, Red)
+	)
+
+	watch.state.players.update(lower)
+	require.True(t, watch.peers.isAnnouncer(testUs, Red, now))
+	require.False(t, watch.peers.active(now))
+
+	// A peer with a lower steam id on our team takes over announcements.
+	watch.peers.seen(lower.SteamID, true, now)
+	require.True(t, watch.peers.active(now))
+	require.False(t, watch.peers.isAnnouncer(testUs, Red, now))
+	require.True(t, watch.peers.isAnnouncer(testUs, Blu, now))
+	require.True(t, watch.peers.isAnnouncer(testUs, Red, now.Add(peerTimeout)))
+
+	// Targets claimed by a peer are skipped.
+	watch.state.players.update(testKickTarget(76561197960265729, 6, Red, "cheater"))
+	watch.peers.claim(6, now)
+
+	watch.update(ctx)
+	require.Empty(t, rcon.commands)
+
+	watch.state.players.update(testKickTarget(76561197960265730, 7, Red, "cheater"))
+
+	watch.update(ctx)
+	require.Equal(t, []string{`callvote kick "7 cheating"`, "say_team [bd:kick=7]"}, rcon.commands)
+}
+
+func TestPeerSpoofing(t *testing.T) {
+	ctx := context.Background()
+	watch, _ := newTestOverwatch(t, true)
+
+	var (
+		now    = time.Now()
+		marked = testKickTarget(76561197960265728, 8, Red, "cheater")
+		queued = testKickTarget(76561197960265729, 9, Red)
+		peer   = testKickTarget(76561197960265730, 10, Red)
+	)
+
+	marked.Personaname = "marked"
+	queued.Personaname = "queued"
+	peer.Personaname = "peer"
+
+	for _, player := range []PlayerState{marked, queued, peer} {
+		watch.state.players.update(player)
+	}
+
+	watch.queue.enqueue(queued.SteamID, KickReasonCheating, 0)
+
+	// A marked player with a lower steam id cannot silence our announcements.
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: marked.Personaname, Message: "gg [bd]"})
+	require.False(t, watch.peers.active(now))
+	require.True(t, watch.peers.isAnnouncer(testUs, Red, now))
+
+	// Nor can players hold off our votes against themselves.
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: marked.Personaname, Message: peerKickClaim(marked.UserID)})
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: queued.Personaname, Message: peerKickClaim(peer.UserID)})
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: peer.Personaname, Message: peerKickClaim(peer.UserID)})
+	require.False(t, watch.peers.claimed(marked.UserID, now))
+	require.False(t, watch.peers.claimed(peer.UserID, now))
+	require.False(t, watch.peers.active(now))
+
+	// Claims against our own targets are only honoured once the peer has been seen announcing, and never for
+	// the players we queued.
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: peer.Personaname, Message: peerKickClaim(marked.UserID)})
+	require.False(t, watch.peers.claimed(marked.UserID, time.Now()))
+	require.True(t, watch.peers.isAnnouncer(testUs, Red, time.Now()))
+
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: peer.Personaname, Message: "gg [bd]"})
+	require.True(t, watch.peers.isAnnouncer(testUs, Red, time.Now()), "Only announcing a matched player counts")
+
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: peer.Personaname, Message: "marked is a cheater [bd]"})
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: peer.Personaname, Message: peerKickClaim(marked.UserID)})
+	watch.peers.onMessage(ctx, LogEvent{Type: EvtMsg, Player: peer.Personaname, Message: peerKickClaim(queued.UserID)})
+	require.True(t, watch.peers.claimed(marked.UserID, time.Now()))
+	require.False(t, watch.peers.claimed(queued.UserID, time.Now()))
+	require.False(t, watch.peers.isAnnouncer(testUs, Red, time.Now()))
+}
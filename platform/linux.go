@@ -6,8 +6,10 @@
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path"
+	"syscall"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/mitchellh/go-ps"
@@ -111,3 +113,18 @@ func (l LinuxPlatform) OpenURL(url string) error {
 
 	return nil
 }
+
+// FileID returns the inode of the file, which is used to tell if a file has been replaced.
+func FileID(filePath string) (uint64, error) {
+	info, errStat := os.Stat(filePath)
+	if errStat != nil {
+		return 0, errors.Join(errStat, ErrFileID)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, ErrFileID
+	}
+
+	return stat.Ino, nil
+}
@@ -19,6 +19,7 @@
 	ErrVDFKey              = errors.New("failed to get child key")
 	ErrVDFValue            = errors.New("invalid vdf value")
 	ErrGameInstallPath     = errors.New("game install path could not be found")
+	ErrFileID              = errors.New("failed to read file id")
 )
 
 // Platform is used to implement operating system specific functionality across linux and windows.
@@ -13,6 +13,7 @@
 	"github.com/andygrunwald/vdf"
 	"github.com/mitchellh/go-ps"
 	"github.com/pkg/browser"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/registry"
 )
 
@@ -194,3 +195,29 @@ func (l WindowsPlatform) OpenURL(url string) error {
 
 	return nil
 }
+
+// FileID returns the NTFS file index of the file, which is used to tell if a file has been replaced.
+func FileID(filePath string) (uint64, error) {
+	pathPtr, errPtr := windows.UTF16PtrFromString(filePath)
+	if errPtr != nil {
+		return 0, errors.Join(errPtr, ErrFileID)
+	}
+
+	handle, errOpen := windows.CreateFile(pathPtr, 0,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if errOpen != nil {
+		return 0, errors.Join(errOpen, ErrFileID)
+	}
+
+	defer func() {
+		_ = windows.CloseHandle(handle)
+	}()
+
+	var info windows.ByHandleFileInformation
+	if errInfo := windows.GetFileInformationByHandle(handle, &info); errInfo != nil {
+		return 0, errors.Join(errInfo, ErrFileID)
+	}
+
+	return uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow), nil
+}
@@ -25,6 +25,8 @@ type PlayerState struct {
 	LastVacBanOn     int64           `json:"last_vac_ban_on"`
 	KillsOn          int64           `json:"kills_on"`
 	DeathsBy         int64           `json:"deaths_by"`
+	SessionKillsOn   int64           `json:"session_kills_on"`
+	SessionDeathsBy  int64           `json:"session_deaths_by"`
 	RageQuits        int64           `json:"rage_quits"`
 	Notes            string          `json:"notes"`
 	Whitelist        bool            `json:"whitelist"`
@@ -59,17 +61,42 @@ type PlayerState struct {
 	Deaths      int  `json:"deaths"`
 	Kills       int  `json:"kills"`
 
+	// Kill feed
+	// Weapons counts the kills made with each weapon during the current session.
+	Weapons map[string]WeaponUsage `json:"weapons"`
+
 	// Misc
 	KPM float64 `json:"kpm"`
 	// Incremented on each kick attempt. Used to cycle through and not attempt the same bot
 	KickAttemptCount int `json:"kick_attempt_count"`
 	// Tracks the duration between announces to chat
-	AnnouncedPartyLast   time.Time           `json:"-"`
-	AnnouncedGeneralLast time.Time           `json:"-"`
-	Friends              []steamweb.Friend   `json:"friends"`
-	OurFriend            bool                `json:"our_friend"`
-	Sourcebans           []SbBanRecord       `json:"sourcebans"`
-	Matches              []rules.MatchResult `json:"matches"`
+	AnnouncedPartyLast   time.Time `json:"-"`
+	AnnouncedGeneralLast time.Time `json:"-"`
+	// Encounter history, TimesSeen includes the current session and LastSeenTogether is the end of the
+	// previous one.
+	TimesSeen            int64     `json:"times_seen"`
+	FirstSeenTogether    time.Time `json:"first_seen_together"`
+	LastSeenTogether     time.Time `json:"last_seen_together"`
+	TimeTogether         int64     `json:"time_together"`
+	EncounterUpdatedLast time.Time `json:"-"`
+	// PartyID is the id of the party the player is likely queued with, 0 if none. AssociatedWith holds the
+	// matched members of that party, the player is not marked because of them.
+	PartyID        int                `json:"party_id"`
+	AssociatedWith steamid.Collection `json:"associated_with"`
+	// Tracks the last negative events against the player, used to detect rage quits
+	KilledByUsLast   time.Time           `json:"-"`
+	VotedAgainstLast time.Time           `json:"-"`
+	Friends          []steamweb.Friend   `json:"friends"`
+	FriendsChecked   bool                `json:"-"`
+	OurFriend        bool                `json:"our_friend"`
+	Sourcebans       []SbBanRecord       `json:"sourcebans"`
+	Matches          []rules.MatchResult `json:"matches"`
+}
+
+// WeaponUsage tracks how many kills, and how many of those kills were crits, a player has made with a weapon.
+type WeaponUsage struct {
+	Kills int `json:"kills"`
+	Crits int `json:"crits"`
 }
 
 func (ps PlayerState) MatchAttr(tags []string) bool {
@@ -84,6 +111,12 @@ func (ps PlayerState) MatchAttr(tags []string) bool {
 	return false
 }
 
+// isInferredMatch returns true for the matches we give players for the current game only, such as having
+// marked friends, rather than those coming from the player lists and rules.
+func isInferredMatch(match rules.MatchResult) bool {
+	return match.MatcherType == friendOfMarkedMatcher
+}
+
 const (
 	playerDisconnect = time.Second * 5
 	playerExpiration = time.Second * 60
@@ -112,6 +145,7 @@ func newPlayer(sid64 steamid.SteamID, name string) PlayerState {
 		CreatedOn:        curTIme,
 		UpdatedOn:        curTIme,
 		ProfileUpdatedOn: curTIme.AddDate(-1, 0, 0),
+		Weapons:          map[string]WeaponUsage{},
 		Matches:          rules.MatchResults{},
 	}
 }
@@ -175,6 +209,7 @@ func loadPlayerOrCreate(ctx context.Context, db store.Querier, sid64 steamid.Ste
 			return PlayerState{}, errors.Join(errGet, errGetPlayer)
 		}
 
+		playerRow.SteamID = sid64.Int64()
 		// use date in past to trigger update queue.
 		playerRow.ProfileUpdatedOn = time.Now().AddDate(-1, 0, 0)
 		playerRow.AvatarHash = defaultAvatarHash
@@ -17,11 +17,11 @@ type processState struct {
 	gameProcessActive  atomic.Bool
 	gameHasStartedOnce atomic.Bool
 	sm                 configManager
-	rcon               rconConnection
+	rcon               rconExecutor
 	platform           platform.Platform
 }
 
-func newProcessState(platform platform.Platform, rcon rconConnection, sm configManager) *processState {
+func newProcessState(platform platform.Platform, rcon rconExecutor, sm configManager) *processState {
 	isRunning, _ := platform.IsGameRunning()
 
 	ps := &processState{
@@ -38,13 +38,17 @@ func newProcessState(platform platform.Platform, rcon rconConnection, sm configM
 	return ps
 }
 
-// launchGame is the main entry point to launching the game. It will install the included addon, write the
+// launchGame is the main entry point to launching the game. It will install the included addon and binds, write the
 // voice bans out if enabled and execute the platform specific launcher command, blocking until exit.
 func (p *processState) launchGame(settings userSettings) {
 	if errInstall := addons.Install(settings.Tf2Dir); errInstall != nil {
 		slog.Error("Error trying to install addon", errAttr(errInstall))
 	}
 
+	if errBinds := installBinds(settings.Tf2Dir); errBinds != nil {
+		slog.Error("Error trying to install binds", errAttr(errBinds))
+	}
+
 	args, errArgs := getLaunchArgs(
 		settings.Rcon.Password,
 		settings.Rcon.Port,
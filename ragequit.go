@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// updateConnected is called with the players marked as connected in the latest g15_dumpplayer output. Players
+// that were connected during the previous update, but no longer are, are treated as having left the server.
+func (s *gameState) updateConnected(ctx context.Context, connected steamid.Collection) {
+	current := make(map[steamid.SteamID]bool, len(connected))
+	for _, sid := range connected {
+		current[sid] = true
+	}
+
+	s.mu.Lock()
+	previous := s.connectedPlayers
+	s.connectedPlayers = current
+	s.mu.Unlock()
+
+	// No players at all means we left the server ourselves, which says nothing about anyone else.
+	if previous == nil || len(current) == 0 {
+		return
+	}
+
+	for sid := range previous {
+		if !current[sid] {
+			s.onKickTargetLeft(ctx, sid)
+			s.onPlayerLeft(ctx, sid)
+		}
+	}
+
+	s.expireKickVote(ctx, time.Now())
+}
+
+// onPlayerLeft checks if a player who just left the server did so shortly after something bad happened
+// to them, being killed by us, a vote against them or their team losing the round, in which case it is
+// counted as a rage quit.
+func (s *gameState) onPlayerLeft(ctx context.Context, sid steamid.SteamID) {
+	settings, errSettings := s.settings.settings(ctx)
+	if errSettings != nil {
+		slog.Error("Failed to read settings", errAttr(errSettings))
+
+		return
+	}
+
+	if sid == settings.GetSteamID() {
+		return
+	}
+
+	player, errPlayer := s.players.bySteamID(sid)
+	if errPlayer != nil {
+		return
+	}
+
+	player.IsConnected = false
+
+	s.mu.RLock()
+	lost := s.roundLost
+	s.mu.RUnlock()
+
+	reason := rageQuitReason(settings, player, lost)
+	if reason == "" {
+		s.players.update(player)
+
+		return
+	}
+
+	player.RageQuits++
+	player.KilledByUsLast = time.Time{}
+	player.VotedAgainstLast = time.Time{}
+
+	if errSave := s.db.PlayerUpdate(ctx, player.toUpdateParams()); errSave != nil {
+		slog.Error("Failed to save rage quit", sidAttr(sid), errAttr(errSave))
+	}
+
+	s.players.update(player)
+
+	slog.Info("Player rage quit", sidAttr(sid), slog.String("name", player.Personaname),
+		slog.String("reason", reason), slog.Int64("rage_quits", player.RageQuits))
+}
+
+// roundLoss is the team that lost the last round and when. Round results come from the srcds
+// `World triggered "Round_Win"` log line, which the client console never shows. They are only known when using
+// the udp log source with a server sending us its logs, so round losses are ignored with any other source.
+type roundLoss struct {
+	team Team
+	at   time.Time
+}
+
+// onRoundWin records the losing team of the round.
+func (s *gameState) onRoundWin(winner Team) {
+	loser := Red
+	if winner == Red {
+		loser = Blu
+	}
+
+	s.mu.Lock()
+	s.roundLost = roundLoss{team: loser, at: time.Now()}
+	s.mu.Unlock()
+}
+
+// rageQuitReason returns why the player leaving counts as a rage quit, or an empty string if it does not.
+func rageQuitReason(settings userSettings, player PlayerState, lost roundLoss) string {
+	switch {
+	case withinWindow(player.KilledByUsLast, settings.RageQuitKillWindow):
+		return "killed"
+	case withinWindow(player.VotedAgainstLast, settings.RageQuitVoteWindow):
+		return "vote"
+	case settings.LogSource == LogSourceUDP && player.Team == lost.team &&
+		withinWindow(lost.at, settings.RageQuitRoundWindow):
+		return "round"
+	default:
+		return ""
+	}
+}
+
+// withinWindow checks if the event occurred within the last windowSecs seconds. A window of 0 disables the check.
+func withinWindow(last time.Time, windowSecs int64) bool {
+	if last.IsZero() || windowSecs <= 0 {
+		return false
+	}
+
+	return time.Since(last) <= time.Duration(windowSecs)*time.Second
+}
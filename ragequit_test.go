@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinWindow(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		last     time.Time
+		window   int64
+		expected bool
+	}{
+		{name: "never", last: time.Time{}, window: 20, expected: false},
+		{name: "disabled", last: now, window: 0, expected: false},
+		{name: "inside", last: now.Add(-time.Second * 10), window: 20, expected: true},
+		{name: "outside", last: now.Add(-time.Second * 30), window: 20, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.expected, withinWindow(testCase.last, testCase.window), testCase.name)
+	}
+}
+
+func TestRageQuitReason(t *testing.T) {
+	var (
+		now      = time.Now()
+		settings = userSettings{Config: store.Config{
+			RageQuitKillWindow: 20, RageQuitVoteWindow: 60, RageQuitRoundWindow: 30, LogSource: LogSourceUDP,
+		}}
+		lost = roundLoss{team: Red, at: now.Add(-time.Second * 5)}
+	)
+
+	testCases := []struct {
+		name     string
+		player   PlayerState
+		lost     roundLoss
+		expected string
+	}{
+		{name: "nothing", player: PlayerState{Team: Blu}, lost: lost, expected: ""},
+		{name: "killed", player: PlayerState{Team: Blu, KilledByUsLast: now}, lost: lost, expected: "killed"},
+		{name: "killed long ago", player: PlayerState{Team: Blu, KilledByUsLast: now.Add(-time.Minute)}, lost: lost, expected: ""},
+		{name: "vote", player: PlayerState{Team: Blu, VotedAgainstLast: now.Add(-time.Second * 30)}, lost: lost, expected: "vote"},
+		{name: "round", player: PlayerState{Team: Red}, lost: lost, expected: "round"},
+		{name: "round long ago", player: PlayerState{Team: Red}, lost: roundLoss{team: Red, at: now.Add(-time.Minute)}, expected: ""},
+		{name: "no round", player: PlayerState{Team: Red}, lost: roundLoss{}, expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.expected, rageQuitReason(settings, testCase.player, testCase.lost), testCase.name)
+	}
+
+	// The console log has no round results, anything claiming to be one is not trusted.
+	settings.LogSource = LogSourceFile
+	require.Empty(t, rageQuitReason(settings, PlayerState{Team: Red}, lost))
+}
+
+func TestUpdateConnected(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		state, _ = newTestState(t, map[string]any{"log_source": LogSourceUDP})
+		killed   = PlayerState{SteamID: steamid.New(76561198084134025), Team: Blu, IsConnected: true, KilledByUsLast: time.Now()}
+		loser    = PlayerState{SteamID: steamid.New(76561197970669109), Team: Blu, IsConnected: true}
+		idle     = PlayerState{SteamID: steamid.New(76561197992870439), Team: Red, IsConnected: true}
+		all      = steamid.Collection{testUs, killed.SteamID, loser.SteamID, idle.SteamID}
+	)
+
+	for _, player := range []PlayerState{{SteamID: testUs, Team: Red, IsConnected: true}, killed, loser, idle} {
+		state.players.update(player)
+	}
+
+	// Nobody can have left before the first update.
+	state.updateConnected(ctx, all)
+
+	state.onRoundWin(Red)
+	state.updateConnected(ctx, steamid.Collection{testUs})
+
+	for _, expected := range []struct {
+		steamID   steamid.SteamID
+		rageQuits int64
+	}{{killed.SteamID, 1}, {loser.SteamID, 1}, {idle.SteamID, 0}} {
+		player, errPlayer := state.players.bySteamID(expected.steamID)
+		require.NoError(t, errPlayer)
+		require.False(t, player.IsConnected)
+		require.Equal(t, expected.rageQuits, player.RageQuits, expected.steamID.String())
+	}
+
+	// We left the server ourselves.
+	state.updateConnected(ctx, all)
+	state.updateConnected(ctx, steamid.Collection{})
+
+	player, errPlayer := state.players.bySteamID(killed.SteamID)
+	require.NoError(t, errPlayer)
+	require.Equal(t, int64(1), player.RageQuits)
+}
@@ -10,6 +10,16 @@
 	"github.com/leighmacdonald/rcon/rcon"
 )
 
+// rconExecutor executes commands on the game client. It allows the rcon connection to be replaced in tests.
+type rconExecutor interface {
+	exec(ctx context.Context, cmd string, large bool) (string, error)
+}
+
+// rconPoster is implemented by executors that can queue a command without waiting for its response.
+type rconPoster interface {
+	post(ctx context.Context, cmd string) error
+}
+
 type rconConnection struct {
 	addr     string
 	password string
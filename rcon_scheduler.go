@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rconPriority orders the outbound commands, lower values are sent first.
+type rconPriority int
+
+const (
+	// rconPriorityCommand is used for kicks, votes and any other command triggered by the user.
+	rconPriorityCommand rconPriority = iota
+	rconPriorityStatus
+	rconPriorityChat
+	rconPriorityCount
+)
+
+func (p rconPriority) String() string {
+	switch p {
+	case rconPriorityCommand:
+		return "command"
+	case rconPriorityStatus:
+		return "status"
+	case rconPriorityChat:
+		return "chat"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// rconChatQueueSize limits how many chat messages can be waiting. Anything past this is dropped, there is no
+	// point announcing things minutes after the fact.
+	rconChatQueueSize = 20
+	// rconMinInterval is the minimum time between any two commands.
+	rconMinInterval = time.Millisecond * 50
+)
+
+// rconDestServerChat is the destination shared by say and say_team. The servers flood protection counts both
+// kinds of message together, so they must share a single limit.
+const rconDestServerChat = "server_chat"
+
+// rconDestinationLimits is the minimum time between commands sent to the same destination. Chat is limited by
+// the server, sending faster than this gets the messages dropped, or us muted.
+var rconDestinationLimits = map[string]time.Duration{ //nolint:gochecknoglobals
+	rconDestServerChat: time.Millisecond * 1500,
+	"say_party":        time.Millisecond * 1000,
+}
+
+// commandPriority determines the priority of the command, and the destination it is rate limited by, from its
+// name.
+func commandPriority(cmd string) (rconPriority, string) {
+	name, _, _ := strings.Cut(strings.TrimSpace(cmd), " ")
+
+	switch name {
+	case "say", "say_team":
+		return rconPriorityChat, rconDestServerChat
+	case "say_party":
+		return rconPriorityChat, name
+	case "status", "tf_lobby_debug", "g15_dumpplayer":
+		return rconPriorityStatus, name
+	default:
+		return rconPriorityCommand, name
+	}
+}
+
+type rconRequest struct {
+	ctx   context.Context //nolint:containedctx
+	cmd   string
+	large bool
+	dest  string
+	done  chan struct{}
+	resp  string
+	err   error
+}
+
+// RCONQueueStats describes the current and historical state of a single priority queue.
+type RCONQueueStats struct {
+	Priority string `json:"priority"`
+	Queued   int    `json:"queued"`
+	MaxQueue int    `json:"max_queue"`
+	Sent     uint64 `json:"sent"`
+	Merged   uint64 `json:"merged"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+// rconScheduler is the single path for all outbound rcon commands. Commands are sent one at a time in priority
+// order, with kicks ahead of status updates, and status updates ahead of chat. Chat is rate limited per
+// destination and identical messages that are still waiting to be sent are merged into one.
+type rconScheduler struct {
+	conn     rconExecutor
+	mu       *sync.Mutex
+	pending  [rconPriorityCount][]*rconRequest
+	stats    [rconPriorityCount]RCONQueueStats
+	nextSend map[string]time.Time
+	lastSend time.Time
+	wake     chan struct{}
+}
+
+func newRconScheduler(conn rconExecutor) *rconScheduler {
+	return &rconScheduler{
+		conn:     conn,
+		mu:       &sync.Mutex{},
+		nextSend: map[string]time.Time{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// exec queues the command and waits for its response.
+func (q *rconScheduler) exec(ctx context.Context, cmd string, large bool) (string, error) {
+	request, errQueue := q.enqueue(ctx, cmd, large)
+	if errQueue != nil {
+		return "", errQueue
+	}
+
+	select {
+	case <-request.done:
+		return request.resp, request.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// post queues the command without waiting for it to be sent. This is used for chat, where the response is not
+// needed and waiting on the destination limit would hold up the caller.
+func (q *rconScheduler) post(ctx context.Context, cmd string) error {
+	_, errQueue := q.enqueue(ctx, cmd, false)
+
+	return errQueue
+}
+
+func (q *rconScheduler) enqueue(ctx context.Context, cmd string, large bool) (*rconRequest, error) {
+	priority, dest := commandPriority(cmd)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := &q.stats[priority]
+
+	if priority == rconPriorityChat {
+		for _, queued := range q.pending[priority] {
+			if queued.cmd == cmd {
+				stats.Merged++
+
+				return queued, nil
+			}
+		}
+
+		if len(q.pending[priority]) >= rconChatQueueSize {
+			stats.Dropped++
+
+			return nil, errRCONQueueFull
+		}
+	}
+
+	request := &rconRequest{ctx: ctx, cmd: cmd, large: large, dest: dest, done: make(chan struct{})}
+	q.pending[priority] = append(q.pending[priority], request)
+	stats.MaxQueue = max(stats.MaxQueue, len(q.pending[priority]))
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return request, nil
+}
+
+// next removes and returns the highest priority request that can be sent now. When nothing can be sent, the
+// time until the next request becomes ready is returned instead, or 0 if there is nothing queued.
+func (q *rconScheduler) next(now time.Time) (*rconRequest, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var wait time.Duration
+
+	if ready := q.lastSend.Add(rconMinInterval); now.Before(ready) {
+		for priority := range q.pending {
+			if len(q.pending[priority]) > 0 {
+				return nil, ready.Sub(now)
+			}
+		}
+
+		return nil, 0
+	}
+
+	for priority := range q.pending {
+		for index, request := range q.pending[priority] {
+			if ready := q.nextSend[request.dest]; now.Before(ready) {
+				if wait == 0 || ready.Sub(now) < wait {
+					wait = ready.Sub(now)
+				}
+
+				continue
+			}
+
+			q.pending[priority] = append(q.pending[priority][:index], q.pending[priority][index+1:]...)
+			q.stats[priority].Sent++
+			q.lastSend = now
+
+			if limit, found := rconDestinationLimits[request.dest]; found {
+				q.nextSend[request.dest] = now.Add(limit)
+			}
+
+			return request, 0
+		}
+	}
+
+	return nil, wait
+}
+
+func (q *rconScheduler) start(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		request, wait := q.next(time.Now())
+		if request != nil {
+			q.send(request)
+
+			continue
+		}
+
+		if wait > 0 {
+			timer.Reset(wait)
+		}
+
+		select {
+		case <-q.wake:
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *rconScheduler) send(request *rconRequest) {
+	defer close(request.done)
+
+	if errCtx := request.ctx.Err(); errCtx != nil {
+		// Nobody is waiting on the response anymore.
+		request.err = errCtx
+
+		return
+	}
+
+	request.resp, request.err = q.conn.exec(request.ctx, request.cmd, request.large)
+	if request.err != nil && !errors.Is(request.err, context.Canceled) {
+		slog.Debug("Scheduled rcon command failed", slog.String("cmd", request.cmd), errAttr(request.err))
+	}
+}
+
+// queueStats returns the stats for each priority queue, highest priority first.
+func (q *rconScheduler) queueStats() []RCONQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := make([]RCONQueueStats, rconPriorityCount)
+	for priority := range q.stats {
+		stats[priority] = q.stats[priority]
+		stats[priority].Priority = rconPriority(priority).String()
+		stats[priority].Queued = len(q.pending[priority])
+	}
+
+	return stats
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRconSchedulerOrdering(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		scheduler = newRconScheduler(rconConnection{})
+		now       = time.Now()
+	)
+
+	for _, cmd := range []string{"say hello", "say hello", "say_team hi", "status", "callvote kick 1", "say_party party"} {
+		_, errQueue := scheduler.enqueue(ctx, cmd, false)
+		require.NoError(t, errQueue)
+	}
+
+	stats := scheduler.queueStats()
+	require.Equal(t, 1, stats[rconPriorityCommand].Queued)
+	require.Equal(t, 1, stats[rconPriorityStatus].Queued)
+	require.Equal(t, 3, stats[rconPriorityChat].Queued)
+	require.Equal(t, uint64(1), stats[rconPriorityChat].Merged)
+
+	var sent []string
+
+	for range 4 {
+		request, wait := scheduler.next(now)
+		require.NotNil(t, request)
+		require.Zero(t, wait)
+
+		sent = append(sent, request.cmd)
+		now = now.Add(rconMinInterval)
+	}
+
+	require.Equal(t, []string{"callvote kick 1", "status", "say hello", "say_party party"}, sent)
+
+	// Team chat shares the limit of all chat, the servers flood protection counts them together.
+	request, wait := scheduler.next(now)
+	require.Nil(t, request)
+	require.Greater(t, wait, time.Duration(0))
+
+	request, _ = scheduler.next(now.Add(rconDestinationLimits[rconDestServerChat]))
+	require.NotNil(t, request)
+	require.Equal(t, "say_team hi", request.cmd)
+}
+
+func TestRconSchedulerPost(t *testing.T) {
+	var (
+		scheduler   = newRconScheduler(rconConnection{})
+		state, _    = newTestState(t, nil)
+		watch       = newOverwatch(state.settings, scheduler, state)
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	)
+
+	defer cancel()
+
+	// The scheduler is not running, so waiting on the messages would never return.
+	for _, msg := range []string{"first", "second", "third"} {
+		require.NoError(t, watch.sendChat(ctx, ChatDestTeam, "%s", msg))
+	}
+
+	require.NoError(t, ctx.Err())
+	require.Equal(t, 3, scheduler.queueStats()[rconPriorityChat].Queued)
+}
+
+func TestRconSchedulerChatLimit(t *testing.T) {
+	scheduler := newRconScheduler(rconConnection{})
+
+	for i := range rconChatQueueSize {
+		_, errQueue := scheduler.enqueue(context.Background(), "say "+string(rune('a'+i)), false)
+		require.NoError(t, errQueue)
+	}
+
+	_, errQueue := scheduler.enqueue(context.Background(), "say overflow", false)
+	require.ErrorIs(t, errQueue, errRCONQueueFull)
+	require.Equal(t, uint64(1), scheduler.queueStats()[rconPriorityChat].Dropped)
+}
@@ -14,8 +14,9 @@
 type MatchResult struct {
 	Origin     string   `json:"origin"` // Title of the list that the match was generated against
 	Attributes []string `json:"attributes"`
-	// Proof       []string
-	MatcherType string `json:"matcher_type"`
+	// Proof is any supporting evidence for matches that are not a simple list lookup
+	Proof       []string `json:"proof,omitempty"`
+	MatcherType string   `json:"matcher_type"`
 }
 
 func (mr MatchResult) HasAttr(attr string) bool {
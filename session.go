@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// SessionPlayer is a player who was present during a game session.
+type SessionPlayer struct {
+	SteamID   steamid.SteamID `json:"steam_id"`
+	Name      string          `json:"name"`
+	Team      Team            `json:"team"`
+	Score     int             `json:"score"`
+	KillsOn   int64           `json:"kills_on"`
+	DeathsBy  int64           `json:"deaths_by"`
+	UpdatedOn time.Time       `json:"updated_on"`
+}
+
+// GameSession is a single continuous stay on a server, which ends on disconnect or map change. EndTime is nil
+// for the currently active session.
+type GameSession struct {
+	SessionID   int64           `json:"session_id"`
+	ServerName  string          `json:"server_name"`
+	Address     string          `json:"address"`
+	MapName     string          `json:"map_name"`
+	Tags        []string        `json:"tags"`
+	StartTime   time.Time       `json:"start_time"`
+	EndTime     *time.Time      `json:"end_time"`
+	PlayerCount int             `json:"player_count"`
+	Players     []SessionPlayer `json:"players"`
+}
+
+func newGameSession(sessionID int64, serverName string, address string, mapName string, tags string,
+	startTime time.Time, endTime sql.NullTime,
+) GameSession {
+	session := GameSession{
+		SessionID:  sessionID,
+		ServerName: serverName,
+		Address:    address,
+		MapName:    mapName,
+		Tags:       []string{},
+		StartTime:  startTime,
+		Players:    []SessionPlayer{},
+	}
+
+	if tags != "" {
+		session.Tags = strings.Split(tags, ",")
+	}
+
+	if endTime.Valid {
+		session.EndTime = &endTime.Time
+	}
+
+	return session
+}
+
+// sessionAddress formats the servers address, or returns an empty string if it is not known yet.
+func sessionAddress(server serverState) string {
+	if server.Addr == nil {
+		return ""
+	}
+
+	return net.JoinHostPort(server.Addr.String(), strconv.Itoa(int(server.Port)))
+}
+
+// syncSession opens a new session once the server name or map is known, or updates the currently open session
+// when any of the server details have changed since it was last saved. A change of map ends the open session
+// and starts a new one.
+func (s *gameState) syncSession(ctx context.Context) {
+	s.mu.RLock()
+	server := s.server
+	current := s.session
+	s.mu.RUnlock()
+
+	if server.ServerName == "" && server.CurrentMap == "" {
+		return
+	}
+
+	params := store.SessionUpdateParams{
+		ServerName: server.ServerName,
+		Address:    sessionAddress(server),
+		MapName:    server.CurrentMap,
+		Tags:       strings.Join(server.Tags, ","),
+		SessionID:  current.SessionID,
+	}
+
+	if current.SessionID != 0 && current.MapName != "" && params.MapName != "" &&
+		current.MapName != params.MapName {
+		s.closeSession(ctx)
+
+		current = store.Session{}
+	}
+
+	if current.SessionID == 0 {
+		s.openSession(ctx, params)
+
+		return
+	}
+
+	if current.ServerName == params.ServerName && current.Address == params.Address &&
+		current.MapName == params.MapName && current.Tags == params.Tags {
+		return
+	}
+
+	if errUpdate := s.db.SessionUpdate(ctx, params); errUpdate != nil {
+		slog.Error("Failed to update session", errAttr(errUpdate))
+
+		return
+	}
+
+	s.mu.Lock()
+	if s.session.SessionID == current.SessionID {
+		s.session.ServerName = params.ServerName
+		s.session.Address = params.Address
+		s.session.MapName = params.MapName
+		s.session.Tags = params.Tags
+	}
+	s.mu.Unlock()
+}
+
+func (s *gameState) openSession(ctx context.Context, params store.SessionUpdateParams) {
+	session, errInsert := s.db.SessionInsert(ctx, store.SessionInsertParams{
+		ServerName: params.ServerName,
+		Address:    params.Address,
+		MapName:    params.MapName,
+		Tags:       params.Tags,
+		StartTime:  time.Now(),
+	})
+	if errInsert != nil {
+		slog.Error("Failed to create session", errAttr(errInsert))
+
+		return
+	}
+
+	s.mu.Lock()
+	s.session = session
+	s.sessionPlayers = map[steamid.SteamID]bool{}
+	s.mu.Unlock()
+
+	// The kills between us and each player only count towards the session they happened in.
+	for _, player := range s.players.all() {
+		if player.SessionKillsOn == 0 && player.SessionDeathsBy == 0 {
+			continue
+		}
+
+		player.SessionKillsOn = 0
+		player.SessionDeathsBy = 0
+
+		s.players.update(player)
+	}
+
+	slog.Debug("Session started", slog.Int64("session_id", session.SessionID),
+		slog.String("server", session.ServerName), slog.String("map", session.MapName))
+}
+
+// currentSessionID returns the id of the open session, or 0 when we are not in a game.
+func (s *gameState) currentSessionID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.session.SessionID
+}
+
+// addSessionPlayer records the player as being present in the current session the first time they are seen.
+func (s *gameState) addSessionPlayer(ctx context.Context, player PlayerState) {
+	s.mu.Lock()
+	sessionID := s.session.SessionID
+	known := s.sessionPlayers[player.SteamID]
+
+	if sessionID != 0 {
+		s.sessionPlayers[player.SteamID] = true
+	}
+	s.mu.Unlock()
+
+	if sessionID == 0 || known {
+		return
+	}
+
+	s.saveSessionPlayer(ctx, sessionID, player)
+}
+
+func (s *gameState) saveSessionPlayer(ctx context.Context, sessionID int64, player PlayerState) {
+	if errSave := s.db.SessionPlayerSave(ctx, store.SessionPlayerSaveParams{
+		SessionID: sessionID,
+		SteamID:   player.SteamID.Int64(),
+		Name:      player.Personaname,
+		Team:      int64(player.Team),
+		Score:     int64(player.Score),
+		UpdatedOn: time.Now(),
+	}); errSave != nil {
+		slog.Error("Failed to save session player", sidAttr(player.SteamID), errAttr(errSave))
+	}
+}
+
+// closeSession ends the current session, saving the final team and score of every player that was present
+// and still known to us.
+func (s *gameState) closeSession(ctx context.Context) {
+	s.endKickVote(ctx, kickVoteCancelled)
+
+	s.mu.Lock()
+	session := s.session
+	present := s.sessionPlayers
+	s.session = store.Session{}
+	s.sessionPlayers = nil
+	s.mu.Unlock()
+
+	if session.SessionID == 0 {
+		return
+	}
+
+	for steamID := range present {
+		player, errPlayer := s.players.bySteamID(steamID)
+		if errPlayer != nil {
+			// Already expired, keep what was recorded when they joined.
+			continue
+		}
+
+		s.saveSessionPlayer(ctx, session.SessionID, player)
+	}
+
+	if errEnd := s.db.SessionEnd(ctx, store.SessionEndParams{
+		EndTime:   sql.NullTime{Time: time.Now(), Valid: true},
+		SessionID: session.SessionID,
+	}); errEnd != nil {
+		slog.Error("Failed to end session", errAttr(errEnd))
+
+		return
+	}
+
+	slog.Debug("Session ended", slog.Int64("session_id", session.SessionID), slog.Int("players", len(present)))
+}
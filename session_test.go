@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	state, _ := newTestState(t, nil)
+
+	// Nothing known about the server yet.
+	state.syncSession(ctx)
+	require.Equal(t, int64(0), state.session.SessionID)
+
+	state.onHostname(hostnameEvent{hostname: "Uncletopia | Seattle | 1"})
+	state.syncSession(ctx)
+	state.onMapName(mapEvent{mapName: "pl_badwater"})
+	state.syncSession(ctx)
+	state.onTags(tagsEvent{tags: []string{"nocrits", "payload"}})
+	state.syncSession(ctx)
+	state.server.Addr = net.ParseIP("1.2.3.4")
+	state.server.Port = 27015
+	state.syncSession(ctx)
+
+	sessionID := state.session.SessionID
+	require.NotEqual(t, int64(0), sessionID)
+
+	player := PlayerState{SteamID: steamid.New(76561197961279983), Personaname: "test player", Team: Red}
+	state.players.update(player)
+	state.addSessionPlayer(ctx, player)
+
+	// Final score is saved when the session ends, a map change on the same server starts a new one.
+	player.Score = 12
+	player.SessionKillsOn = 3
+	player.SessionDeathsBy = 2
+	state.players.update(player)
+	state.onMapName(mapEvent{mapName: "pl_upward"})
+	state.syncSession(ctx)
+
+	current, errCurrent := state.players.bySteamID(player.SteamID)
+	require.NoError(t, errCurrent)
+	require.Zero(t, current.SessionKillsOn)
+	require.Zero(t, current.SessionDeathsBy)
+
+	nextSessionID := state.session.SessionID
+	require.NotEqual(t, int64(0), nextSessionID)
+	require.NotEqual(t, sessionID, nextSessionID)
+	require.Equal(t, "pl_upward", state.session.MapName)
+	require.Equal(t, "Uncletopia | Seattle | 1", state.session.ServerName)
+
+	state.addSessionPlayer(ctx, player)
+	state.closeSession(ctx)
+	require.Equal(t, int64(0), state.session.SessionID)
+
+	sessions, errSessions := state.db.Sessions(ctx, player.SteamID.Int64())
+	require.NoError(t, errSessions)
+	require.Len(t, sessions, 2)
+
+	next := newGameSession(sessions[0].SessionID, sessions[0].ServerName, sessions[0].Address,
+		sessions[0].MapName, sessions[0].Tags, sessions[0].StartTime, sessions[0].EndTime)
+	require.Equal(t, nextSessionID, next.SessionID)
+	require.Equal(t, "pl_upward", next.MapName)
+	require.NotNil(t, next.EndTime)
+
+	session := newGameSession(sessions[1].SessionID, sessions[1].ServerName, sessions[1].Address,
+		sessions[1].MapName, sessions[1].Tags, sessions[1].StartTime, sessions[1].EndTime)
+	require.Equal(t, sessionID, session.SessionID)
+	require.Equal(t, "Uncletopia | Seattle | 1", session.ServerName)
+	require.Equal(t, "pl_badwater", session.MapName)
+	require.Equal(t, "1.2.3.4:27015", session.Address)
+	require.Equal(t, []string{"nocrits", "payload"}, session.Tags)
+	require.NotNil(t, session.EndTime)
+	require.Equal(t, int64(1), sessions[1].PlayerCount)
+
+	players, errPlayers := state.db.SessionPlayers(ctx, sessionID)
+	require.NoError(t, errPlayers)
+	require.Len(t, players, 1)
+	require.Equal(t, int64(12), players[0].Score)
+	require.Equal(t, int64(Red), players[0].Team)
+
+	otherPlayer := steamid.New(76561197960265728)
+	other, errOther := state.db.Sessions(ctx, otherPlayer.Int64())
+	require.NoError(t, errOther)
+	require.Empty(t, other)
+}
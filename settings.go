@@ -6,9 +6,11 @@
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/kirsle/configdir"
@@ -55,6 +57,14 @@ type ListConfig struct {
 	ModeDebug   = "debug"
 )
 
+// Log sources used to receive game events.
+const (
+	// LogSourceFile tails the local console.log file.
+	LogSourceFile = "file"
+	// LogSourceUDP listens for srcds remote log packets (logaddress_add).
+	LogSourceUDP = "udp"
+)
+
 type configManager struct {
 	// Path to config used when reading userSettings
 	configRoot string
@@ -92,6 +102,10 @@ func (sm configManager) settings(ctx context.Context) (userSettings, error) {
 		defaultSteamRoot: sm.platform.DefaultSteamRoot(),
 	}
 
+	if config.KickTags != "" {
+		settings.KickTags = strings.Split(config.KickTags, ",")
+	}
+
 	lists, errLists := sm.queries.Lists(ctx)
 	if errLists != nil {
 		return settings, errors.Join(errLists, errQueryLists)
@@ -178,6 +192,16 @@ func validateSettings(settings userSettings) error {
 		}
 	}
 
+	switch settings.LogSource {
+	case LogSourceFile:
+	case LogSourceUDP:
+		if _, errResolve := net.ResolveUDPAddr("udp4", settings.UdpListenAddr); errResolve != nil {
+			err = errors.Join(err, errResolve, errSettingAddress)
+		}
+	default:
+		err = errors.Join(err, errSettingLogSource)
+	}
+
 	return err
 }
 
@@ -189,26 +213,42 @@ func (sm configManager) save(ctx context.Context, settings userSettings) error {
 	}
 
 	if err := sm.queries.ConfigUpdate(ctx, store.ConfigUpdateParams{
-		SteamID:                 settings.SteamID,
-		SteamDir:                settings.SteamDir,
-		Tf2Dir:                  settings.Tf2Dir,
-		AutoLaunchGame:          settings.AutoLaunchGame,
-		AutoCloseOnGameExit:     settings.AutoCloseOnGameExit,
-		BdApiEnabled:            settings.BdApiEnabled,
-		BdApiAddress:            settings.BdApiAddress,
-		ApiKey:                  settings.ApiKey,
-		SystrayEnabled:          settings.SystrayEnabled,
-		VoiceBansEnabled:        settings.VoiceBansEnabled,
-		RconStatic:              settings.RconStatic,
-		HttpEnabled:             settings.HttpEnabled,
-		HttpListenAddr:          settings.HttpListenAddr,
-		PlayerExpiredTimeout:    settings.PlayerExpiredTimeout,
-		PlayerDisconnectTimeout: settings.DisconnectedTimeout,
-		RunMode:                 settings.RunMode,
-		LogLevel:                settings.LogLevel,
-		RconAddress:             settings.RconAddress,
-		RconPort:                settings.RconPort,
-		RconPassword:            settings.RconPassword,
+		SteamID:                  settings.SteamID,
+		SteamDir:                 settings.SteamDir,
+		Tf2Dir:                   settings.Tf2Dir,
+		AutoLaunchGame:           settings.AutoLaunchGame,
+		AutoCloseOnGameExit:      settings.AutoCloseOnGameExit,
+		BdApiEnabled:             settings.BdApiEnabled,
+		BdApiAddress:             settings.BdApiAddress,
+		ApiKey:                   settings.ApiKey,
+		SystrayEnabled:           settings.SystrayEnabled,
+		DisconnectedTimeout:      settings.DisconnectedTimeout,
+		DiscordPresenceEnabled:   settings.DiscordPresenceEnabled,
+		KickerEnabled:            settings.KickerEnabled,
+		ChatWarningsEnabled:      settings.ChatWarningsEnabled,
+		DebugLogEnabled:          settings.DebugLogEnabled,
+		VoiceBansEnabled:         settings.VoiceBansEnabled,
+		RconStatic:               settings.RconStatic,
+		HttpEnabled:              settings.HttpEnabled,
+		HttpListenAddr:           settings.HttpListenAddr,
+		PlayerExpiredTimeout:     settings.PlayerExpiredTimeout,
+		PlayerDisconnectTimeout:  settings.DisconnectedTimeout,
+		RunMode:                  settings.RunMode,
+		LogLevel:                 settings.LogLevel,
+		RconAddress:              settings.RconAddress,
+		RconPort:                 settings.RconPort,
+		RconPassword:             settings.RconPassword,
+		RageQuitKillWindow:       settings.RageQuitKillWindow,
+		RageQuitVoteWindow:       settings.RageQuitVoteWindow,
+		LogSource:                settings.LogSource,
+		UdpListenAddr:            settings.UdpListenAddr,
+		UdpLogSecret:             settings.UdpLogSecret,
+		EventJournalEnabled:      settings.EventJournalEnabled,
+		EventJournalRetention:    settings.EventJournalRetention,
+		EncounterRetention:       settings.EncounterRetention,
+		FriendsOfMarkedThreshold: settings.FriendsOfMarkedThreshold,
+		KickTags:                 strings.Join(settings.KickTags, ","),
+		RageQuitRoundWindow:      settings.RageQuitRoundWindow,
 	}); err != nil {
 		return errors.Join(err, errConfigSave)
 	}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSave(t *testing.T) {
+	ctx := context.Background()
+	state, _ := newTestState(t, nil)
+	settingsMgr := state.settings
+
+	settings, errSettings := settingsMgr.settings(ctx)
+	require.NoError(t, errSettings)
+
+	settings.ApiKey = strings.Repeat("a", apiKeyLen)
+	settings.DisconnectedTimeout = 120
+	settings.DiscordPresenceEnabled = !settings.DiscordPresenceEnabled
+	settings.KickerEnabled = !settings.KickerEnabled
+	settings.ChatWarningsEnabled = !settings.ChatWarningsEnabled
+	settings.DebugLogEnabled = !settings.DebugLogEnabled
+
+	require.NoError(t, settingsMgr.save(ctx, settings))
+
+	saved, errSaved := settingsMgr.settings(ctx)
+	require.NoError(t, errSaved)
+	require.Equal(t, settings.DisconnectedTimeout, saved.DisconnectedTimeout)
+	require.Equal(t, settings.DiscordPresenceEnabled, saved.DiscordPresenceEnabled)
+	require.Equal(t, settings.KickerEnabled, saved.KickerEnabled)
+	require.Equal(t, settings.ChatWarningsEnabled, saved.ChatWarningsEnabled)
+	require.Equal(t, settings.DebugLogEnabled, saved.DebugLogEnabled)
+}
@@ -5,7 +5,9 @@
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,12 +20,23 @@
 	sqlite3 "modernc.org/sqlite/lib"
 )
 
-var errPlayerNotFound = errors.New("player not found")
+var (
+	errPlayerNotFound      = errors.New("player not found")
+	errPlayerNameAmbiguous = errors.New("multiple players share the name")
+)
 
 type serverState struct {
-	ServerName string    `json:"server_name"`
-	Addr       net.IP    `json:"-"`
-	Port       uint16    `json:"-"`
+	ServerName string `json:"server_name"`
+	Addr       net.IP `json:"addr"`
+	Port       uint16 `json:"port"`
+	// SDR is set when connected through a valve steam datagram relay (169.254.0.0/16). These addresses
+	// are only valid for our own session and cannot be used by anyone else to join the server.
+	SDR        bool      `json:"sdr"`
+	Version    string    `json:"version"`
+	Secure     bool      `json:"secure"`
+	Humans     int       `json:"humans"`
+	Bots       int       `json:"bots"`
+	MaxPlayers int       `json:"max_players"`
 	CurrentMap string    `json:"current_map"`
 	Tags       []string  `json:"-"`
 	LastUpdate time.Time `json:"last_update"`
@@ -58,6 +71,49 @@ func (state *playerStates) byName(name string) (PlayerState, error) {
 	return PlayerState{}, errPlayerNotFound
 }
 
+// byUniqueName returns the connected player with the name only when nobody else connected shares it. Chat lines
+// only identify the sender by name, and bots routinely copy the names of real players, so this should be used
+// whenever a chat line is trusted to come from a specific player.
+func (state *playerStates) byUniqueName(name string) (PlayerState, error) {
+	state.RLock()
+	defer state.RUnlock()
+
+	var (
+		found PlayerState
+		count int
+	)
+
+	for _, knownPlayer := range state.activePlayers {
+		if knownPlayer.Personaname == name && knownPlayer.IsConnected {
+			found = knownPlayer
+			count++
+		}
+	}
+
+	switch count {
+	case 0:
+		return PlayerState{}, errPlayerNotFound
+	case 1:
+		return found, nil
+	default:
+		return PlayerState{}, errPlayerNameAmbiguous
+	}
+}
+
+// byUserID returns a connected player by the server assigned user id, as shown in the status output.
+func (state *playerStates) byUserID(userID int) (PlayerState, error) {
+	state.RLock()
+	defer state.RUnlock()
+
+	for _, knownPlayer := range state.activePlayers {
+		if knownPlayer.UserID == userID && knownPlayer.IsConnected {
+			return knownPlayer, nil
+		}
+	}
+
+	return PlayerState{}, errPlayerNotFound
+}
+
 // bySteamID returns a player currently being tracked in the game state. If connectedOnly is true, then
 // players who have timed out already are ignored.
 func (state *playerStates) bySteamID(sid64 steamid.SteamID) (PlayerState, error) {
@@ -124,31 +180,20 @@ func (state *playerStates) remove(sid64 steamid.SteamID) {
 	state.activePlayers = valid
 }
 
-// checkPlayerStates will run a check against the current player state for matches.
-func (state *playerStates) checkPlayerState(ctx context.Context, re *rules.Engine, player PlayerState, validTeam Team, announcer overwatch) {
-	if !player.IsConnected || len(player.Matches) > 0 {
-		return
-	}
-
-	if matchSteam := re.MatchSteam(player.SteamID); matchSteam != nil {
-		player.Matches = matchSteam
-
-		if validTeam == player.Team {
-			announcer.announceMatch(ctx, player, matchSteam)
-			// state.update(*player)
-		}
-	} else if player.Personaname != "" {
-		if matchName := re.MatchName(player.Personaname); matchName != nil && validTeam == player.Team {
-			player.Matches = matchName
-
-			if validTeam == player.Team {
-				announcer.announceMatch(ctx, player, matchName)
-				// state.update(*player)
-			}
-		}
-	}
+// KillFeedEntry is a single kill event recorded during the current session.
+type KillFeedEntry struct {
+	Killer    string          `json:"killer"`
+	KillerSID steamid.SteamID `json:"killer_sid"`
+	Victim    string          `json:"victim"`
+	VictimSID steamid.SteamID `json:"victim_sid"`
+	Weapon    string          `json:"weapon"`
+	Crit      bool            `json:"crit"`
+	CreatedOn time.Time       `json:"created_on"`
 }
 
+// maxKillFeedSize limits how many kill events are kept in memory for a single session.
+const maxKillFeedSize = 1000
+
 type gameState struct {
 	mu                 *sync.RWMutex
 	playerDataChan     chan playerDataUpdate
@@ -158,12 +203,24 @@ type gameState struct {
 	players            *playerStates
 	db                 store.Querier
 	server             serverState
-	store              store.Querier
-	rcon               rconConnection
+	killFeed           []KillFeedEntry
+	connectedPlayers   map[steamid.SteamID]bool
+	roundLost          roundLoss
+	session            store.Session
+	kickVote           activeKickVote
+	sessionPlayers     map[steamid.SteamID]bool
+	parties            []Party
+	// Only accessed by the cleanup handler.
+	encountersPrunedLast time.Time
+	// Only accessed by the status updater.
+	party partyTracker
+	store store.Querier
+	rcon  rconExecutor
+	re    *rules.Engine
 }
 
-func newGameState(store store.Querier, settings configManager, playerState *playerStates, rcon rconConnection,
-	db store.Querier,
+func newGameState(store store.Querier, settings configManager, playerState *playerStates, rcon rconExecutor,
+	db store.Querier, re *rules.Engine,
 ) *gameState {
 	return &gameState{
 		mu:                 &sync.RWMutex{},
@@ -172,14 +229,24 @@ func newGameState(store store.Querier, settings configManager, playerState *play
 		players:            playerState,
 		rcon:               rcon,
 		db:                 db,
+		re:                 re,
 		server:             serverState{},
 		playerDataChan:     make(chan playerDataUpdate),
-		eventChan:          make(chan LogEvent),
+		eventChan:          make(chan LogEvent, eventQueueSize),
 		profileUpdateQueue: make(chan steamid.SteamID),
 	}
 }
 
 func (s *gameState) start(ctx context.Context) {
+	// Sessions left open by a previous run that did not shut down cleanly.
+	if errClose := s.db.SessionsCloseOpen(ctx); errClose != nil {
+		slog.Error("Failed to close previous sessions", errAttr(errClose))
+	}
+
+	if errClose := s.db.KickVotesCloseOpen(ctx); errClose != nil {
+		slog.Error("Failed to close previous kick votes", errAttr(errClose))
+	}
+
 	for {
 		select {
 		case playerData := <-s.playerDataChan:
@@ -198,15 +265,37 @@ func (s *gameState) start(ctx context.Context) {
 				}
 			}
 			slog.Debug("received event", slog.Int("type", int(evt.Type)))
+
+			if evt.Replayed {
+				s.onReplayedEvent(ctx, evt)
+
+				continue
+			}
+
 			switch evt.Type { //nolint:exhaustive
 			case EvtMap:
 				s.onMapName(mapEvent{mapName: evt.MetaData})
+				s.syncSession(ctx)
 			case EvtHostname:
 				s.onHostname(hostnameEvent{hostname: evt.MetaData})
+				s.syncSession(ctx)
 			case EvtTags:
 				s.onTags(tagsEvent{tags: strings.Split(evt.MetaData, ",")})
+				s.syncSession(ctx)
 			case EvtAddress:
 				s.onEventAddress(evt)
+				s.syncSession(ctx)
+			case EvtVersion:
+				s.onVersion(parseVersion(evt.MetaData))
+			case EvtPlayerCount:
+				playerCount, errCount := parsePlayerCount(evt.MetaData)
+				if errCount != nil {
+					slog.Error("Failed to parse player count", errAttr(errCount))
+
+					continue
+				}
+
+				s.onPlayerCount(playerCount)
 			case EvtStatusID:
 				s.onStatus(ctx, evt.PlayerSID, statusEvent{
 					ping:      evt.PlayerPing,
@@ -215,12 +304,16 @@ func (s *gameState) start(ctx context.Context) {
 					connected: evt.PlayerConnected,
 				})
 			case EvtDisconnect:
+				s.closeSession(ctx)
 				s.onMapChange()
 			case EvtKill:
 				s.onKill(ctx, evt)
+			case EvtRoundWin:
+				s.onRoundWin(evt.Team)
 			case EvtMsg:
 			case EvtConnect:
 			case EvtLobby:
+				s.onLobby(ctx, evt)
 			case EvtAny:
 			}
 		case <-ctx.Done():
@@ -230,21 +323,50 @@ func (s *gameState) start(ctx context.Context) {
 }
 
 func (s *gameState) onEventAddress(evt LogEvent) {
-	pcs := strings.Split(evt.MetaData, ":")
+	host, portValue, errSplit := net.SplitHostPort(evt.MetaData)
+	if errSplit != nil {
+		slog.Error("Failed to parse address", errAttr(errSplit), slog.String("addr", evt.MetaData))
+
+		return
+	}
 
-	_, errPort := strconv.ParseUint(pcs[1], 10, 16)
+	port, errPort := strconv.ParseUint(portValue, 10, 16)
 	if errPort != nil {
-		slog.Error("Failed to parse port: %v", errAttr(errPort), slog.String("port", pcs[1]))
+		slog.Error("Failed to parse port: %v", errAttr(errPort), slog.String("port", portValue))
 
 		return
 	}
 
-	parsedIP := net.ParseIP(pcs[0])
+	parsedIP := net.ParseIP(host)
 	if parsedIP == nil {
-		slog.Error("Failed to parse ip", slog.String("ip", pcs[0]))
+		slog.Error("Failed to parse ip", slog.String("ip", host))
 
 		return
 	}
+
+	s.mu.Lock()
+	s.server.Addr = parsedIP
+	s.server.Port = uint16(port)
+	s.server.SDR = parsedIP.IsLinkLocalUnicast()
+	s.server.LastUpdate = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *gameState) onVersion(evt versionEvent) {
+	s.mu.Lock()
+	s.server.Version = evt.version
+	s.server.Secure = evt.secure
+	s.server.LastUpdate = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *gameState) onPlayerCount(evt playerCountEvent) {
+	s.mu.Lock()
+	s.server.Humans = evt.humans
+	s.server.Bots = evt.bots
+	s.server.MaxPlayers = evt.maxPlayers
+	s.server.LastUpdate = time.Now()
+	s.mu.Unlock()
 }
 
 // cleanupHandler is used to track of players and their expiration times. It will remove and reset expired players
@@ -274,7 +396,8 @@ func (s *gameState) startCleanupHandler(ctx context.Context, settingsMgr *config
 			slog.Debug("Delete update input received", slog.String("state", "start"))
 
 			s.mu.Lock()
-			if time.Since(s.server.LastUpdate) > time.Second*time.Duration(settings.PlayerDisconnectTimeout) {
+			timedOut := time.Since(s.server.LastUpdate) > time.Second*time.Duration(settings.PlayerDisconnectTimeout)
+			if timedOut {
 				name := s.server.ServerName
 				if !strings.HasPrefix(name, disconnectMsg) {
 					name = fmt.Sprintf("%s %s", disconnectMsg, name)
@@ -285,6 +408,12 @@ func (s *gameState) startCleanupHandler(ctx context.Context, settingsMgr *config
 
 			s.mu.Unlock()
 
+			if timedOut {
+				s.closeSession(ctx)
+			}
+
+			s.pruneEncounters(ctx, settings.EncounterRetention)
+
 			for _, player := range s.players.all() {
 				if player.IsConnected {
 					player.IsConnected = false
@@ -331,13 +460,19 @@ func (s *gameState) applyRemoteData(ctx context.Context, data playerDataUpdate,
 	// Friends
 	player.Friends = data.friends
 	ourSteamID := settings.GetSteamID()
-	for _, friend := range data.friends {
+	friends := make(steamid.Collection, len(data.friends))
+
+	for index, friend := range data.friends {
+		friends[index] = friend.SteamID
+
 		if friend.SteamID == ourSteamID {
 			player.OurFriend = true
-			break
 		}
 	}
 
+	player = applyFriendsOfMarked(s.re, player, friends, settings.FriendsOfMarkedThreshold)
+	player.FriendsChecked = true
+
 	// meta
 	player.UpdatedOn = time.Now()
 	player.ProfileUpdatedOn = player.UpdatedOn
@@ -367,34 +502,124 @@ func (s *gameState) onKill(ctx context.Context, evt LogEvent) {
 		return
 	}
 
-	ke := killEvent{victimName: evt.Victim, sourceName: evt.Player}
+	ke := killEvent{victimName: evt.Victim, sourceName: evt.Player, weapon: evt.Weapon, crit: evt.Crit}
+
+	entry := KillFeedEntry{
+		Killer:    ke.sourceName,
+		Victim:    ke.victimName,
+		Weapon:    ke.weapon,
+		Crit:      ke.crit,
+		CreatedOn: evt.Timestamp,
+	}
+
+	if killer, errKiller := s.players.byName(ke.sourceName); errKiller == nil {
+		entry.KillerSID = killer.SteamID
+	}
+
+	if victim, errVictim := s.players.byName(ke.victimName); errVictim == nil {
+		entry.VictimSID = victim.SteamID
+	}
+
+	s.addKillFeed(entry)
+
 	src, srcErr := s.players.byName(ke.sourceName)
 	if srcErr != nil {
 		return
 	}
 
-	target, targetErr := s.players.byName(ke.sourceName)
-	if targetErr != nil {
+	target, targetErr := s.players.byName(ke.victimName)
+	if targetErr != nil || target.SteamID == src.SteamID {
 		return
 	}
 
 	src.Kills++
 	target.Deaths++
 
+	// Copy before modifying, the current map may be shared with readers of the previous state.
+	weapons := maps.Clone(src.Weapons)
+	if weapons == nil {
+		weapons = map[string]WeaponUsage{}
+	}
+
+	usage := weapons[ke.weapon]
+	usage.Kills++
+
+	if ke.crit {
+		usage.Crits++
+	}
+
+	weapons[ke.weapon] = usage
+	src.Weapons = weapons
+
 	ourSteamID := settings.GetSteamID()
 
 	if target.SteamID == ourSteamID {
 		src.DeathsBy++
+		src.SessionDeathsBy++
+
+		s.saveKills(ctx, src.SteamID, 0, 1)
 	}
 
 	if src.SteamID == ourSteamID {
 		target.KillsOn++
+		target.SessionKillsOn++
+		target.KilledByUsLast = time.Now()
+
+		s.saveKills(ctx, target.SteamID, 1, 0)
 	}
 
 	s.players.update(src)
 	s.players.update(target)
 }
 
+// saveKills adds to the all-time and current session kill counts between us and the opponent.
+func (s *gameState) saveKills(ctx context.Context, opponent steamid.SteamID, killsOn int64, deathsBy int64) {
+	if errSave := s.db.PlayerKillsAdd(ctx, store.PlayerKillsAddParams{
+		KillsOn:  killsOn,
+		DeathsBy: deathsBy,
+		SteamID:  opponent.Int64(),
+	}); errSave != nil {
+		slog.Error("Failed to save kills", sidAttr(opponent), errAttr(errSave))
+	}
+
+	s.mu.RLock()
+	sessionID := s.session.SessionID
+	s.mu.RUnlock()
+
+	if sessionID == 0 {
+		return
+	}
+
+	if errSave := s.db.SessionPlayerKillsAdd(ctx, store.SessionPlayerKillsAddParams{
+		SessionID: sessionID,
+		SteamID:   opponent.Int64(),
+		KillsOn:   killsOn,
+		DeathsBy:  deathsBy,
+		UpdatedOn: time.Now(),
+	}); errSave != nil {
+		slog.Error("Failed to save session kills", sidAttr(opponent), errAttr(errSave))
+	}
+}
+
+// addKillFeed appends a kill to the current sessions kill feed, discarding the oldest entries once full.
+func (s *gameState) addKillFeed(entry KillFeedEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.killFeed = append(s.killFeed, entry)
+	if len(s.killFeed) > maxKillFeedSize {
+		s.killFeed = slices.Clone(s.killFeed[len(s.killFeed)-maxKillFeedSize:])
+	}
+}
+
+// KillFeed returns a copy of the kills recorded during the current session.
+func (s *gameState) KillFeed() []KillFeedEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.killFeed)
+}
+
 func (s *gameState) getPlayerOrCreate(ctx context.Context, steamID steamid.SteamID) (PlayerState, error) {
 	player, errPlayer := s.players.bySteamID(steamID)
 	if errPlayer != nil {
@@ -428,24 +653,14 @@ func (s *gameState) onStatus(ctx context.Context, steamID steamid.SteamID, evt s
 
 	if player.Personaname != evt.name {
 		player.Personaname = evt.name
-		errAddName := s.store.UserNameSave(ctx, store.UserNameSaveParams{
-			SteamID:   player.SteamID.Int64(),
-			Name:      player.Personaname,
-			CreatedOn: time.Now(),
-		})
-		if errAddName != nil {
-			var sqliteErr *sqlite.Error
-			if errors.As(errAddName, &sqliteErr) {
-				if sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
-					slog.Error("Could not save new user name", errAttr(errAddName))
-				}
-			} else {
-				slog.Error("Could not save new user name", errAttr(errAddName))
-			}
-		}
+		s.saveUserName(ctx, player.SteamID, player.Personaname)
 	}
 
-	s.players.update(player)
+	player = s.updateEncounter(ctx, player)
+	player = s.checkStoredFriends(ctx, player)
+
+	s.players.update(s.applyRuleMatches(player))
+	s.addSessionPlayer(ctx, player)
 
 	// Trigger update of external data if it's been long enough, or the player is new to us.
 	if time.Since(player.ProfileUpdatedOn) > time.Hour*24 {
@@ -462,6 +677,99 @@ func (s *gameState) onStatus(ctx context.Context, steamID steamid.SteamID, evt s
 		slog.Int("connected", int(evt.connected.Seconds())))
 }
 
+// onLobby handles the tf_lobby_debug output. The lobby lists everyone assigned to the match, including players
+// that are still loading in, so they are added to the active players early to have them checked against the
+// rules before they have even spawned. The team is set here as well so it is still known when the g15 data is
+// missing or incomplete.
+func (s *gameState) onLobby(ctx context.Context, evt LogEvent) {
+	player, errPlayer := s.getPlayerOrCreate(ctx, evt.PlayerSID)
+	if errPlayer != nil {
+		slog.Error("Failed to get lobby player", errAttr(errPlayer))
+
+		return
+	}
+
+	player.Team = evt.Team
+	player.UpdatedOn = time.Now()
+
+	s.players.update(s.applyRuleMatches(player))
+}
+
+// applyRuleMatches checks the player against the rules engine, first by steam id and then by name if it is
+// known. Players that already have rule matches are returned unchanged.
+func (s *gameState) applyRuleMatches(player PlayerState) PlayerState {
+	hasRuleMatch := slices.ContainsFunc(player.Matches, func(match rules.MatchResult) bool {
+		return !isInferredMatch(match)
+	})
+
+	if s.re == nil || hasRuleMatch {
+		return player
+	}
+
+	matches := s.re.MatchSteam(player.SteamID)
+	if len(matches) == 0 && player.Personaname != "" {
+		matches = s.re.MatchName(player.Personaname)
+	}
+
+	if len(matches) == 0 {
+		return player
+	}
+
+	// Keep any friend_of_marked match.
+	player.Matches = append(matches, player.Matches...)
+
+	slog.Info("Player matched rules", sidAttr(player.SteamID),
+		slog.String("name", player.Personaname), slog.Int("matches", len(matches)))
+
+	return player
+}
+
+// saveUserName records a new name in the players name history.
+func (s *gameState) saveUserName(ctx context.Context, steamID steamid.SteamID, name string) {
+	errAddName := s.store.UserNameSave(ctx, store.UserNameSaveParams{
+		SteamID:   steamID.Int64(),
+		Name:      name,
+		CreatedOn: time.Now(),
+	})
+	if errAddName != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(errAddName, &sqliteErr) {
+			if sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+				slog.Error("Could not save new user name", errAttr(errAddName))
+			}
+		} else {
+			slog.Error("Could not save new user name", errAttr(errAddName))
+		}
+	}
+}
+
+// onReplayedEvent handles events that happened before we started. The current game state could be completely
+// different by now, so these are only used to fill in player history and are never applied to the active
+// players, which could otherwise lead to announcing or kicking players that are not even in the game.
+func (s *gameState) onReplayedEvent(ctx context.Context, evt LogEvent) {
+	if evt.Type != EvtStatusID {
+		return
+	}
+
+	player, errPlayer := loadPlayerOrCreate(ctx, s.store, evt.PlayerSID)
+	if errPlayer != nil {
+		slog.Error("Failed to load replayed player", errAttr(errPlayer))
+
+		return
+	}
+
+	if player.Personaname == evt.Player {
+		return
+	}
+
+	player.Personaname = evt.Player
+	s.saveUserName(ctx, player.SteamID, player.Personaname)
+
+	if errSave := s.db.PlayerUpdate(ctx, player.toUpdateParams()); errSave != nil {
+		slog.Error("Failed to save replayed player name", errAttr(errSave))
+	}
+}
+
 func (s *gameState) onTags(evt tagsEvent) {
 	s.mu.Lock()
 	s.server.Tags = evt.tags
@@ -495,13 +803,19 @@ func (s *gameState) onMapChange() {
 		player.IsConnected = true
 		player.Kills = 0
 		player.Deaths = 0
+		player.SessionKillsOn = 0
+		player.SessionDeathsBy = 0
 		player.MapTimeStart = time.Now()
 		player.MapTime = 0
+		player.Weapons = map[string]WeaponUsage{}
 
 		s.players.update(player)
 	}
 	s.mu.Lock()
-	s.server.CurrentMap = ""
-	s.server.ServerName = ""
+	// Everything but the last update time belongs to the previous server.
+	s.server = serverState{LastUpdate: s.server.LastUpdate}
+	s.killFeed = nil
+	s.connectedPlayers = nil
+	s.roundLost = roundLoss{}
 	s.mu.Unlock()
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leighmacdonald/bd/rules"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnKill(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		state, _ = newTestState(t, nil)
+		killer   = PlayerState{SteamID: steamid.New(76561198057999536), Personaname: "killer"}
+		victim   = PlayerState{SteamID: steamid.New(76561198084134025), Personaname: "victim"}
+	)
+
+	state.players.update(killer)
+	state.players.update(victim)
+
+	state.onKill(ctx, LogEvent{Type: EvtKill, Player: "killer", Victim: "victim", Weapon: "scattergun", Crit: true})
+	state.onKill(ctx, LogEvent{Type: EvtKill, Player: "killer", Victim: "victim", Weapon: "scattergun"})
+
+	updatedKiller, errKiller := state.players.bySteamID(killer.SteamID)
+	require.NoError(t, errKiller)
+	require.Equal(t, 2, updatedKiller.Kills)
+	require.Equal(t, 0, updatedKiller.Deaths)
+	require.Equal(t, map[string]WeaponUsage{"scattergun": {Kills: 2, Crits: 1}}, updatedKiller.Weapons)
+
+	updatedVictim, errVictim := state.players.bySteamID(victim.SteamID)
+	require.NoError(t, errVictim)
+	require.Equal(t, 0, updatedVictim.Kills)
+	require.Equal(t, 2, updatedVictim.Deaths)
+	require.Empty(t, updatedVictim.Weapons)
+
+	feed := state.KillFeed()
+	require.Len(t, feed, 2)
+	require.Equal(t, killer.SteamID, feed[0].KillerSID)
+	require.Equal(t, victim.SteamID, feed[0].VictimSID)
+	require.True(t, feed[0].Crit)
+}
+
+func TestOnKillStats(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		opponent = steamid.New(76561198084134025)
+		state, _ = newTestState(t, nil)
+	)
+
+	for sid, name := range map[steamid.SteamID]string{testUs: "us", opponent: "them"} {
+		player, errPlayer := loadPlayerOrCreate(ctx, state.db, sid)
+		require.NoError(t, errPlayer)
+
+		player.Personaname = name
+		state.players.update(player)
+	}
+
+	state.onHostname(hostnameEvent{hostname: "test server"})
+	state.syncSession(ctx)
+
+	state.onKill(ctx, LogEvent{Type: EvtKill, Player: "us", Victim: "them", Weapon: "scattergun"})
+	state.onKill(ctx, LogEvent{Type: EvtKill, Player: "us", Victim: "them", Weapon: "scattergun"})
+	state.onKill(ctx, LogEvent{Type: EvtKill, Player: "them", Victim: "us", Weapon: "sniperrifle"})
+
+	them, errThem := state.players.bySteamID(opponent)
+	require.NoError(t, errThem)
+	require.Equal(t, int64(2), them.KillsOn)
+	require.Equal(t, int64(1), them.DeathsBy)
+	require.Equal(t, int64(2), them.SessionKillsOn)
+	require.Equal(t, int64(1), them.SessionDeathsBy)
+	require.Equal(t, 1, them.Kills)
+	require.Equal(t, 2, them.Deaths)
+
+	row, errRow := state.db.Player(ctx, opponent.Int64())
+	require.NoError(t, errRow)
+	require.Equal(t, int64(2), row.KillsOn)
+	require.Equal(t, int64(1), row.DeathsBy)
+
+	sessions, errSessions := state.db.PlayerSessionKills(ctx, opponent.Int64())
+	require.NoError(t, errSessions)
+	require.Len(t, sessions, 1)
+	require.Equal(t, "test server", sessions[0].ServerName)
+	require.Equal(t, int64(2), sessions[0].KillsOn)
+	require.Equal(t, int64(1), sessions[0].DeathsBy)
+
+	// Nothing is counted against ourselves.
+	self, errSelf := state.db.PlayerSessionKills(ctx, testUs.Int64())
+	require.NoError(t, errSelf)
+	require.Empty(t, self)
+}
+
+func TestOnLobby(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		engine   = rules.New()
+		marked   = steamid.New(76561198084134025)
+		clean    = steamid.New(76561197970669109)
+		state, _ = newTestState(t, nil)
+	)
+
+	require.NoError(t, engine.Mark(rules.MarkOpts{SteamID: marked, Attributes: []string{"cheater"}}))
+
+	state.re = engine
+
+	// Lobby members are known before they show up in the status output.
+	state.onLobby(ctx, LogEvent{Type: EvtLobby, PlayerSID: marked, Team: Blu})
+	state.onLobby(ctx, LogEvent{Type: EvtLobby, PlayerSID: clean, Team: Red})
+
+	player, errPlayer := state.players.bySteamID(marked)
+	require.NoError(t, errPlayer)
+	require.Equal(t, Blu, player.Team)
+	require.False(t, player.IsConnected)
+	require.Len(t, player.Matches, 1)
+	require.True(t, player.MatchAttr([]string{"cheater"}))
+
+	player, errPlayer = state.players.bySteamID(clean)
+	require.NoError(t, errPlayer)
+	require.Equal(t, Red, player.Team)
+	require.Empty(t, player.Matches)
+
+	// Team changes in later lobby updates are followed, without matching the rules again.
+	state.onLobby(ctx, LogEvent{Type: EvtLobby, PlayerSID: marked, Team: Red})
+
+	player, errPlayer = state.players.bySteamID(marked)
+	require.NoError(t, errPlayer)
+	require.Equal(t, Red, player.Team)
+	require.Len(t, player.Matches, 1)
+}
@@ -6,19 +6,21 @@
 	"errors"
 	"log/slog"
 	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
 )
 
-// statusUpdater is responsible for periodically sending `status` and `g15_dumpplayer` command to the
-// game client.
+// statusUpdater is responsible for periodically sending `status`, `tf_lobby_debug` and `g15_dumpplayer` command
+// to the game client.
 type statusUpdater struct {
-	rcon       rconConnection
+	rcon       rconExecutor
 	process    *processState
 	state      *gameState
 	updateRate time.Duration
 	g15        g15Parser
 }
 
-func newStatusUpdater(rcon rconConnection, process *processState, state *gameState, updateRate time.Duration) statusUpdater {
+func newStatusUpdater(rcon rconExecutor, process *processState, state *gameState, updateRate time.Duration) statusUpdater {
 	return statusUpdater{
 		rcon:       rcon,
 		process:    process,
@@ -48,8 +50,8 @@ func (s statusUpdater) start(ctx context.Context) {
 	}
 }
 
-// updatePlayerState fetches the current game state over rcon using both the `status` and `g15_dumpplayer` command
-// output. The results are then parsed and applied to the current player and server states.
+// updatePlayerState fetches the current game state over rcon using the `status`, `tf_lobby_debug` and
+// `g15_dumpplayer` command output. The results are then parsed and applied to the current player and server states.
 func (s statusUpdater) updatePlayerState(ctx context.Context) error {
 	// Sent to client, response via log output
 	_, errStatus := s.rcon.exec(ctx, "status", true)
@@ -58,6 +60,11 @@ func (s statusUpdater) updatePlayerState(ctx context.Context) error {
 		return errors.Join(errStatus, errRCONStatus)
 	}
 
+	// Also sent to client, the lobby members are picked up by the log parser.
+	if _, errLobby := s.rcon.exec(ctx, "tf_lobby_debug", true); errLobby != nil {
+		return errors.Join(errLobby, errRCONLobby)
+	}
+
 	dumpPlayer, errDumpPlayer := s.rcon.exec(ctx, "g15_dumpplayer", true)
 	if errDumpPlayer != nil {
 		return errors.Join(errDumpPlayer, errRCONG15)
@@ -68,12 +75,18 @@ func (s statusUpdater) updatePlayerState(ctx context.Context) error {
 		return errors.Join(errG15, errG15Parse)
 	}
 
+	var connected steamid.Collection
+
 	for index, sid := range dump.SteamID {
 		if index == 0 || index > 32 || !sid.Valid() {
 			// Actual data always starts at 1
 			continue
 		}
 
+		if dump.Connected[index] {
+			connected = append(connected, sid)
+		}
+
 		player, errPlayer := s.state.players.bySteamID(sid)
 		if errPlayer != nil {
 			// status command is what we use to add players to the active game.
@@ -100,5 +113,8 @@ func (s statusUpdater) updatePlayerState(ctx context.Context) error {
 		s.state.players.update(player)
 	}
 
+	s.state.updateConnected(ctx, connected)
+	s.state.updateParties(ctx)
+
 	return nil
 }
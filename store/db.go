@@ -24,12 +24,39 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.announcementUpdateStmt, err = db.PrepareContext(ctx, announcementUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query AnnouncementUpdate: %w", err)
+	}
+	if q.announcementsStmt, err = db.PrepareContext(ctx, announcements); err != nil {
+		return nil, fmt.Errorf("error preparing query Announcements: %w", err)
+	}
 	if q.configStmt, err = db.PrepareContext(ctx, config); err != nil {
 		return nil, fmt.Errorf("error preparing query Config: %w", err)
 	}
 	if q.configUpdateStmt, err = db.PrepareContext(ctx, configUpdate); err != nil {
 		return nil, fmt.Errorf("error preparing query ConfigUpdate: %w", err)
 	}
+	if q.encounterStmt, err = db.PrepareContext(ctx, encounter); err != nil {
+		return nil, fmt.Errorf("error preparing query Encounter: %w", err)
+	}
+	if q.encounterSaveStmt, err = db.PrepareContext(ctx, encounterSave); err != nil {
+		return nil, fmt.Errorf("error preparing query EncounterSave: %w", err)
+	}
+	if q.encounterServersStmt, err = db.PrepareContext(ctx, encounterServers); err != nil {
+		return nil, fmt.Errorf("error preparing query EncounterServers: %w", err)
+	}
+	if q.encountersDeleteOlderStmt, err = db.PrepareContext(ctx, encountersDeleteOlder); err != nil {
+		return nil, fmt.Errorf("error preparing query EncountersDeleteOlder: %w", err)
+	}
+	if q.eventSaveStmt, err = db.PrepareContext(ctx, eventSave); err != nil {
+		return nil, fmt.Errorf("error preparing query EventSave: %w", err)
+	}
+	if q.eventsStmt, err = db.PrepareContext(ctx, events); err != nil {
+		return nil, fmt.Errorf("error preparing query Events: %w", err)
+	}
+	if q.eventsDeleteOlderStmt, err = db.PrepareContext(ctx, eventsDeleteOlder); err != nil {
+		return nil, fmt.Errorf("error preparing query EventsDeleteOlder: %w", err)
+	}
 	if q.friendsStmt, err = db.PrepareContext(ctx, friends); err != nil {
 		return nil, fmt.Errorf("error preparing query Friends: %w", err)
 	}
@@ -39,6 +66,27 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.friendsInsertStmt, err = db.PrepareContext(ctx, friendsInsert); err != nil {
 		return nil, fmt.Errorf("error preparing query FriendsInsert: %w", err)
 	}
+	if q.kickVoteEndStmt, err = db.PrepareContext(ctx, kickVoteEnd); err != nil {
+		return nil, fmt.Errorf("error preparing query KickVoteEnd: %w", err)
+	}
+	if q.kickVoteInsertStmt, err = db.PrepareContext(ctx, kickVoteInsert); err != nil {
+		return nil, fmt.Errorf("error preparing query KickVoteInsert: %w", err)
+	}
+	if q.kickVoteStatsByServerStmt, err = db.PrepareContext(ctx, kickVoteStatsByServer); err != nil {
+		return nil, fmt.Errorf("error preparing query KickVoteStatsByServer: %w", err)
+	}
+	if q.kickVoteStatsByTagStmt, err = db.PrepareContext(ctx, kickVoteStatsByTag); err != nil {
+		return nil, fmt.Errorf("error preparing query KickVoteStatsByTag: %w", err)
+	}
+	if q.kickVoteTagSaveStmt, err = db.PrepareContext(ctx, kickVoteTagSave); err != nil {
+		return nil, fmt.Errorf("error preparing query KickVoteTagSave: %w", err)
+	}
+	if q.kickVotesStmt, err = db.PrepareContext(ctx, kickVotes); err != nil {
+		return nil, fmt.Errorf("error preparing query KickVotes: %w", err)
+	}
+	if q.kickVotesCloseOpenStmt, err = db.PrepareContext(ctx, kickVotesCloseOpen); err != nil {
+		return nil, fmt.Errorf("error preparing query KickVotesCloseOpen: %w", err)
+	}
 	if q.linksStmt, err = db.PrepareContext(ctx, links); err != nil {
 		return nil, fmt.Errorf("error preparing query Links: %w", err)
 	}
@@ -63,6 +111,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listsUpdateStmt, err = db.PrepareContext(ctx, listsUpdate); err != nil {
 		return nil, fmt.Errorf("error preparing query ListsUpdate: %w", err)
 	}
+	if q.logOffsetStmt, err = db.PrepareContext(ctx, logOffset); err != nil {
+		return nil, fmt.Errorf("error preparing query LogOffset: %w", err)
+	}
+	if q.logOffsetSaveStmt, err = db.PrepareContext(ctx, logOffsetSave); err != nil {
+		return nil, fmt.Errorf("error preparing query LogOffsetSave: %w", err)
+	}
 	if q.messageSaveStmt, err = db.PrepareContext(ctx, messageSave); err != nil {
 		return nil, fmt.Errorf("error preparing query MessageSave: %w", err)
 	}
@@ -75,12 +129,48 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.playerInsertStmt, err = db.PrepareContext(ctx, playerInsert); err != nil {
 		return nil, fmt.Errorf("error preparing query PlayerInsert: %w", err)
 	}
+	if q.playerKillsAddStmt, err = db.PrepareContext(ctx, playerKillsAdd); err != nil {
+		return nil, fmt.Errorf("error preparing query PlayerKillsAdd: %w", err)
+	}
 	if q.playerSearchStmt, err = db.PrepareContext(ctx, playerSearch); err != nil {
 		return nil, fmt.Errorf("error preparing query PlayerSearch: %w", err)
 	}
+	if q.playerSessionKillsStmt, err = db.PrepareContext(ctx, playerSessionKills); err != nil {
+		return nil, fmt.Errorf("error preparing query PlayerSessionKills: %w", err)
+	}
 	if q.playerUpdateStmt, err = db.PrepareContext(ctx, playerUpdate); err != nil {
 		return nil, fmt.Errorf("error preparing query PlayerUpdate: %w", err)
 	}
+	if q.sessionStmt, err = db.PrepareContext(ctx, session); err != nil {
+		return nil, fmt.Errorf("error preparing query Session: %w", err)
+	}
+	if q.sessionCoPlayersStmt, err = db.PrepareContext(ctx, sessionCoPlayers); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionCoPlayers: %w", err)
+	}
+	if q.sessionEndStmt, err = db.PrepareContext(ctx, sessionEnd); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionEnd: %w", err)
+	}
+	if q.sessionInsertStmt, err = db.PrepareContext(ctx, sessionInsert); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionInsert: %w", err)
+	}
+	if q.sessionPlayerKillsAddStmt, err = db.PrepareContext(ctx, sessionPlayerKillsAdd); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionPlayerKillsAdd: %w", err)
+	}
+	if q.sessionPlayerSaveStmt, err = db.PrepareContext(ctx, sessionPlayerSave); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionPlayerSave: %w", err)
+	}
+	if q.sessionPlayersStmt, err = db.PrepareContext(ctx, sessionPlayers); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionPlayers: %w", err)
+	}
+	if q.sessionUpdateStmt, err = db.PrepareContext(ctx, sessionUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionUpdate: %w", err)
+	}
+	if q.sessionsStmt, err = db.PrepareContext(ctx, sessions); err != nil {
+		return nil, fmt.Errorf("error preparing query Sessions: %w", err)
+	}
+	if q.sessionsCloseOpenStmt, err = db.PrepareContext(ctx, sessionsCloseOpen); err != nil {
+		return nil, fmt.Errorf("error preparing query SessionsCloseOpen: %w", err)
+	}
 	if q.sourcebansStmt, err = db.PrepareContext(ctx, sourcebans); err != nil {
 		return nil, fmt.Errorf("error preparing query Sourcebans: %w", err)
 	}
@@ -101,6 +191,16 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 
 func (q *Queries) Close() error {
 	var err error
+	if q.announcementUpdateStmt != nil {
+		if cerr := q.announcementUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing announcementUpdateStmt: %w", cerr)
+		}
+	}
+	if q.announcementsStmt != nil {
+		if cerr := q.announcementsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing announcementsStmt: %w", cerr)
+		}
+	}
 	if q.configStmt != nil {
 		if cerr := q.configStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing configStmt: %w", cerr)
@@ -111,6 +211,41 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing configUpdateStmt: %w", cerr)
 		}
 	}
+	if q.encounterStmt != nil {
+		if cerr := q.encounterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing encounterStmt: %w", cerr)
+		}
+	}
+	if q.encounterSaveStmt != nil {
+		if cerr := q.encounterSaveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing encounterSaveStmt: %w", cerr)
+		}
+	}
+	if q.encounterServersStmt != nil {
+		if cerr := q.encounterServersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing encounterServersStmt: %w", cerr)
+		}
+	}
+	if q.encountersDeleteOlderStmt != nil {
+		if cerr := q.encountersDeleteOlderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing encountersDeleteOlderStmt: %w", cerr)
+		}
+	}
+	if q.eventSaveStmt != nil {
+		if cerr := q.eventSaveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing eventSaveStmt: %w", cerr)
+		}
+	}
+	if q.eventsStmt != nil {
+		if cerr := q.eventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing eventsStmt: %w", cerr)
+		}
+	}
+	if q.eventsDeleteOlderStmt != nil {
+		if cerr := q.eventsDeleteOlderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing eventsDeleteOlderStmt: %w", cerr)
+		}
+	}
 	if q.friendsStmt != nil {
 		if cerr := q.friendsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing friendsStmt: %w", cerr)
@@ -126,6 +261,41 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing friendsInsertStmt: %w", cerr)
 		}
 	}
+	if q.kickVoteEndStmt != nil {
+		if cerr := q.kickVoteEndStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing kickVoteEndStmt: %w", cerr)
+		}
+	}
+	if q.kickVoteInsertStmt != nil {
+		if cerr := q.kickVoteInsertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing kickVoteInsertStmt: %w", cerr)
+		}
+	}
+	if q.kickVoteStatsByServerStmt != nil {
+		if cerr := q.kickVoteStatsByServerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing kickVoteStatsByServerStmt: %w", cerr)
+		}
+	}
+	if q.kickVoteStatsByTagStmt != nil {
+		if cerr := q.kickVoteStatsByTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing kickVoteStatsByTagStmt: %w", cerr)
+		}
+	}
+	if q.kickVoteTagSaveStmt != nil {
+		if cerr := q.kickVoteTagSaveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing kickVoteTagSaveStmt: %w", cerr)
+		}
+	}
+	if q.kickVotesStmt != nil {
+		if cerr := q.kickVotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing kickVotesStmt: %w", cerr)
+		}
+	}
+	if q.kickVotesCloseOpenStmt != nil {
+		if cerr := q.kickVotesCloseOpenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing kickVotesCloseOpenStmt: %w", cerr)
+		}
+	}
 	if q.linksStmt != nil {
 		if cerr := q.linksStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing linksStmt: %w", cerr)
@@ -166,6 +336,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listsUpdateStmt: %w", cerr)
 		}
 	}
+	if q.logOffsetStmt != nil {
+		if cerr := q.logOffsetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing logOffsetStmt: %w", cerr)
+		}
+	}
+	if q.logOffsetSaveStmt != nil {
+		if cerr := q.logOffsetSaveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing logOffsetSaveStmt: %w", cerr)
+		}
+	}
 	if q.messageSaveStmt != nil {
 		if cerr := q.messageSaveStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing messageSaveStmt: %w", cerr)
@@ -186,16 +366,76 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing playerInsertStmt: %w", cerr)
 		}
 	}
+	if q.playerKillsAddStmt != nil {
+		if cerr := q.playerKillsAddStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing playerKillsAddStmt: %w", cerr)
+		}
+	}
 	if q.playerSearchStmt != nil {
 		if cerr := q.playerSearchStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing playerSearchStmt: %w", cerr)
 		}
 	}
+	if q.playerSessionKillsStmt != nil {
+		if cerr := q.playerSessionKillsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing playerSessionKillsStmt: %w", cerr)
+		}
+	}
 	if q.playerUpdateStmt != nil {
 		if cerr := q.playerUpdateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing playerUpdateStmt: %w", cerr)
 		}
 	}
+	if q.sessionStmt != nil {
+		if cerr := q.sessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionStmt: %w", cerr)
+		}
+	}
+	if q.sessionCoPlayersStmt != nil {
+		if cerr := q.sessionCoPlayersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionCoPlayersStmt: %w", cerr)
+		}
+	}
+	if q.sessionEndStmt != nil {
+		if cerr := q.sessionEndStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionEndStmt: %w", cerr)
+		}
+	}
+	if q.sessionInsertStmt != nil {
+		if cerr := q.sessionInsertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionInsertStmt: %w", cerr)
+		}
+	}
+	if q.sessionPlayerKillsAddStmt != nil {
+		if cerr := q.sessionPlayerKillsAddStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionPlayerKillsAddStmt: %w", cerr)
+		}
+	}
+	if q.sessionPlayerSaveStmt != nil {
+		if cerr := q.sessionPlayerSaveStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionPlayerSaveStmt: %w", cerr)
+		}
+	}
+	if q.sessionPlayersStmt != nil {
+		if cerr := q.sessionPlayersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionPlayersStmt: %w", cerr)
+		}
+	}
+	if q.sessionUpdateStmt != nil {
+		if cerr := q.sessionUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionUpdateStmt: %w", cerr)
+		}
+	}
+	if q.sessionsStmt != nil {
+		if cerr := q.sessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionsStmt: %w", cerr)
+		}
+	}
+	if q.sessionsCloseOpenStmt != nil {
+		if cerr := q.sessionsCloseOpenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionsCloseOpenStmt: %w", cerr)
+		}
+	}
 	if q.sourcebansStmt != nil {
 		if cerr := q.sourcebansStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing sourcebansStmt: %w", cerr)
@@ -258,61 +498,121 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                   DBTX
-	tx                   *sql.Tx
-	configStmt           *sql.Stmt
-	configUpdateStmt     *sql.Stmt
-	friendsStmt          *sql.Stmt
-	friendsDeleteStmt    *sql.Stmt
-	friendsInsertStmt    *sql.Stmt
-	linksStmt            *sql.Stmt
-	linksDeleteStmt      *sql.Stmt
-	linksInsertStmt      *sql.Stmt
-	linksUpdateStmt      *sql.Stmt
-	listsStmt            *sql.Stmt
-	listsDeleteStmt      *sql.Stmt
-	listsInsertStmt      *sql.Stmt
-	listsUpdateStmt      *sql.Stmt
-	messageSaveStmt      *sql.Stmt
-	messagesStmt         *sql.Stmt
-	playerStmt           *sql.Stmt
-	playerInsertStmt     *sql.Stmt
-	playerSearchStmt     *sql.Stmt
-	playerUpdateStmt     *sql.Stmt
-	sourcebansStmt       *sql.Stmt
-	sourcebansDeleteStmt *sql.Stmt
-	sourcebansInsertStmt *sql.Stmt
-	userNameSaveStmt     *sql.Stmt
-	userNamesStmt        *sql.Stmt
+	db                        DBTX
+	tx                        *sql.Tx
+	announcementUpdateStmt    *sql.Stmt
+	announcementsStmt         *sql.Stmt
+	configStmt                *sql.Stmt
+	configUpdateStmt          *sql.Stmt
+	encounterStmt             *sql.Stmt
+	encounterSaveStmt         *sql.Stmt
+	encounterServersStmt      *sql.Stmt
+	encountersDeleteOlderStmt *sql.Stmt
+	eventSaveStmt             *sql.Stmt
+	eventsStmt                *sql.Stmt
+	eventsDeleteOlderStmt     *sql.Stmt
+	friendsStmt               *sql.Stmt
+	friendsDeleteStmt         *sql.Stmt
+	friendsInsertStmt         *sql.Stmt
+	kickVoteEndStmt           *sql.Stmt
+	kickVoteInsertStmt        *sql.Stmt
+	kickVoteStatsByServerStmt *sql.Stmt
+	kickVoteStatsByTagStmt    *sql.Stmt
+	kickVoteTagSaveStmt       *sql.Stmt
+	kickVotesStmt             *sql.Stmt
+	kickVotesCloseOpenStmt    *sql.Stmt
+	linksStmt                 *sql.Stmt
+	linksDeleteStmt           *sql.Stmt
+	linksInsertStmt           *sql.Stmt
+	linksUpdateStmt           *sql.Stmt
+	listsStmt                 *sql.Stmt
+	listsDeleteStmt           *sql.Stmt
+	listsInsertStmt           *sql.Stmt
+	listsUpdateStmt           *sql.Stmt
+	logOffsetStmt             *sql.Stmt
+	logOffsetSaveStmt         *sql.Stmt
+	messageSaveStmt           *sql.Stmt
+	messagesStmt              *sql.Stmt
+	playerStmt                *sql.Stmt
+	playerInsertStmt          *sql.Stmt
+	playerKillsAddStmt        *sql.Stmt
+	playerSearchStmt          *sql.Stmt
+	playerSessionKillsStmt    *sql.Stmt
+	playerUpdateStmt          *sql.Stmt
+	sessionStmt               *sql.Stmt
+	sessionCoPlayersStmt      *sql.Stmt
+	sessionEndStmt            *sql.Stmt
+	sessionInsertStmt         *sql.Stmt
+	sessionPlayerKillsAddStmt *sql.Stmt
+	sessionPlayerSaveStmt     *sql.Stmt
+	sessionPlayersStmt        *sql.Stmt
+	sessionUpdateStmt         *sql.Stmt
+	sessionsStmt              *sql.Stmt
+	sessionsCloseOpenStmt     *sql.Stmt
+	sourcebansStmt            *sql.Stmt
+	sourcebansDeleteStmt      *sql.Stmt
+	sourcebansInsertStmt      *sql.Stmt
+	userNameSaveStmt          *sql.Stmt
+	userNamesStmt             *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                   tx,
-		tx:                   tx,
-		configStmt:           q.configStmt,
-		configUpdateStmt:     q.configUpdateStmt,
-		friendsStmt:          q.friendsStmt,
-		friendsDeleteStmt:    q.friendsDeleteStmt,
-		friendsInsertStmt:    q.friendsInsertStmt,
-		linksStmt:            q.linksStmt,
-		linksDeleteStmt:      q.linksDeleteStmt,
-		linksInsertStmt:      q.linksInsertStmt,
-		linksUpdateStmt:      q.linksUpdateStmt,
-		listsStmt:            q.listsStmt,
-		listsDeleteStmt:      q.listsDeleteStmt,
-		listsInsertStmt:      q.listsInsertStmt,
-		listsUpdateStmt:      q.listsUpdateStmt,
-		messageSaveStmt:      q.messageSaveStmt,
-		messagesStmt:         q.messagesStmt,
-		playerStmt:           q.playerStmt,
-		playerInsertStmt:     q.playerInsertStmt,
-		playerSearchStmt:     q.playerSearchStmt,
-		playerUpdateStmt:     q.playerUpdateStmt,
-		sourcebansStmt:       q.sourcebansStmt,
-		sourcebansDeleteStmt: q.sourcebansDeleteStmt,
-		sourcebansInsertStmt: q.sourcebansInsertStmt,
-		userNameSaveStmt:     q.userNameSaveStmt,
-		userNamesStmt:        q.userNamesStmt,
+		db:                        tx,
+		tx:                        tx,
+		announcementUpdateStmt:    q.announcementUpdateStmt,
+		announcementsStmt:         q.announcementsStmt,
+		configStmt:                q.configStmt,
+		configUpdateStmt:          q.configUpdateStmt,
+		encounterStmt:             q.encounterStmt,
+		encounterSaveStmt:         q.encounterSaveStmt,
+		encounterServersStmt:      q.encounterServersStmt,
+		encountersDeleteOlderStmt: q.encountersDeleteOlderStmt,
+		eventSaveStmt:             q.eventSaveStmt,
+		eventsStmt:                q.eventsStmt,
+		eventsDeleteOlderStmt:     q.eventsDeleteOlderStmt,
+		friendsStmt:               q.friendsStmt,
+		friendsDeleteStmt:         q.friendsDeleteStmt,
+		friendsInsertStmt:         q.friendsInsertStmt,
+		kickVoteEndStmt:           q.kickVoteEndStmt,
+		kickVoteInsertStmt:        q.kickVoteInsertStmt,
+		kickVoteStatsByServerStmt: q.kickVoteStatsByServerStmt,
+		kickVoteStatsByTagStmt:    q.kickVoteStatsByTagStmt,
+		kickVoteTagSaveStmt:       q.kickVoteTagSaveStmt,
+		kickVotesStmt:             q.kickVotesStmt,
+		kickVotesCloseOpenStmt:    q.kickVotesCloseOpenStmt,
+		linksStmt:                 q.linksStmt,
+		linksDeleteStmt:           q.linksDeleteStmt,
+		linksInsertStmt:           q.linksInsertStmt,
+		linksUpdateStmt:           q.linksUpdateStmt,
+		listsStmt:                 q.listsStmt,
+		listsDeleteStmt:           q.listsDeleteStmt,
+		listsInsertStmt:           q.listsInsertStmt,
+		listsUpdateStmt:           q.listsUpdateStmt,
+		logOffsetStmt:             q.logOffsetStmt,
+		logOffsetSaveStmt:         q.logOffsetSaveStmt,
+		messageSaveStmt:           q.messageSaveStmt,
+		messagesStmt:              q.messagesStmt,
+		playerStmt:                q.playerStmt,
+		playerInsertStmt:          q.playerInsertStmt,
+		playerKillsAddStmt:        q.playerKillsAddStmt,
+		playerSearchStmt:          q.playerSearchStmt,
+		playerSessionKillsStmt:    q.playerSessionKillsStmt,
+		playerUpdateStmt:          q.playerUpdateStmt,
+		sessionStmt:               q.sessionStmt,
+		sessionCoPlayersStmt:      q.sessionCoPlayersStmt,
+		sessionEndStmt:            q.sessionEndStmt,
+		sessionInsertStmt:         q.sessionInsertStmt,
+		sessionPlayerKillsAddStmt: q.sessionPlayerKillsAddStmt,
+		sessionPlayerSaveStmt:     q.sessionPlayerSaveStmt,
+		sessionPlayersStmt:        q.sessionPlayersStmt,
+		sessionUpdateStmt:         q.sessionUpdateStmt,
+		sessionsStmt:              q.sessionsStmt,
+		sessionsCloseOpenStmt:     q.sessionsCloseOpenStmt,
+		sourcebansStmt:            q.sourcebansStmt,
+		sourcebansDeleteStmt:      q.sourcebansDeleteStmt,
+		sourcebansInsertStmt:      q.sourcebansInsertStmt,
+		userNameSaveStmt:          q.userNameSaveStmt,
+		userNamesStmt:             q.userNamesStmt,
 	}
 }
@@ -0,0 +1,5 @@
+alter table config
+    drop column rage_quit_kill_window;
+
+alter table config
+    drop column rage_quit_vote_window;
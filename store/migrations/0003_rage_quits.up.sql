@@ -0,0 +1,5 @@
+alter table config
+    add column rage_quit_kill_window integer not null default 20 check ( rage_quit_kill_window >= 0 );
+
+alter table config
+    add column rage_quit_vote_window integer not null default 60 check ( rage_quit_vote_window >= 0 );
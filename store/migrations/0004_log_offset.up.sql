@@ -0,0 +1,9 @@
+create table if not exists log_offset
+(
+    path        text primary key,
+    file_offset integer not null default 0,
+    file_size   integer not null default 0,
+    inode       integer not null default 0,
+    mod_time    date    not null,
+    updated_on  date    not null default (DATETIME('now'))
+);
@@ -0,0 +1,8 @@
+alter table config
+    drop column log_source;
+
+alter table config
+    drop column udp_listen_addr;
+
+alter table config
+    drop column udp_log_secret;
@@ -0,0 +1,8 @@
+alter table config
+    add column log_source text not null default 'file' check ( log_source IN ('file', 'udp') );
+
+alter table config
+    add column udp_listen_addr text not null default '0.0.0.0:27115';
+
+alter table config
+    add column udp_log_secret integer not null default 0 check ( udp_log_secret >= 0 );
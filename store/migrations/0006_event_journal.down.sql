@@ -0,0 +1,7 @@
+drop table if exists events;
+
+alter table config
+    drop column event_journal_enabled;
+
+alter table config
+    drop column event_journal_retention;
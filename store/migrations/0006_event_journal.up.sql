@@ -0,0 +1,28 @@
+create table if not exists events
+(
+    event_id        integer primary key,
+    session_id      text    not null,
+    event_type      integer not null,
+    player_name     text    not null default '',
+    steam_id        integer not null default 0,
+    victim_name     text    not null default '',
+    victim_steam_id integer not null default 0,
+    message         text    not null default '',
+    meta_data       text    not null default '',
+    team            integer not null default 0,
+    weapon          text    not null default '',
+    crit            boolean not null default false,
+    dead            boolean not null default false,
+    team_only       boolean not null default false,
+    created_on      date    not null
+);
+
+create index if not exists idx_events_created_on on events (created_on);
+create index if not exists idx_events_steam_id on events (steam_id);
+create index if not exists idx_events_victim_steam_id on events (victim_steam_id);
+
+alter table config
+    add column event_journal_enabled boolean not null default false;
+
+alter table config
+    add column event_journal_retention integer not null default 30 check ( event_journal_retention >= 0 );
@@ -0,0 +1,5 @@
+alter table player_names
+    drop column source;
+
+alter table player_messages
+    drop column source;
@@ -0,0 +1,5 @@
+alter table player_names
+    add column source text not null default '';
+
+alter table player_messages
+    add column source text not null default '';
@@ -0,0 +1,3 @@
+drop table if exists session_players;
+
+drop table if exists sessions;
@@ -0,0 +1,26 @@
+create table if not exists sessions
+(
+    session_id  integer primary key,
+    server_name text not null default '',
+    address     text not null default '',
+    map_name    text not null default '',
+    tags        text not null default '',
+    start_time  date not null,
+    end_time    date
+);
+
+create index if not exists idx_sessions_start_time on sessions (start_time);
+
+create table if not exists session_players
+(
+    session_id integer not null,
+    steam_id   integer not null,
+    name       text    not null default '',
+    team       integer not null default 0,
+    score      integer not null default 0,
+    updated_on date    not null,
+    foreign key (session_id) references sessions (session_id) on delete cascade,
+    primary key (session_id, steam_id)
+);
+
+create index if not exists idx_session_players_steam_id on session_players (steam_id);
@@ -0,0 +1,4 @@
+drop table if exists player_encounters;
+
+alter table config
+    drop column encounter_retention;
@@ -0,0 +1,15 @@
+create table if not exists player_encounters
+(
+    steam_id        integer primary key,
+    first_seen      date    not null,
+    last_seen       date    not null,
+    previous_seen   date    not null default 0,
+    total_time      integer not null default 0,
+    sessions        integer not null default 1,
+    last_session_id integer not null default 0
+);
+
+create index if not exists idx_player_encounters_last_seen on player_encounters (last_seen);
+
+alter table config
+    add column encounter_retention integer not null default 180 check ( encounter_retention >= 0 );
@@ -0,0 +1,5 @@
+alter table session_players
+    drop column kills_on;
+
+alter table session_players
+    drop column deaths_by;
@@ -0,0 +1,5 @@
+alter table session_players
+    add column kills_on integer not null default 0;
+
+alter table session_players
+    add column deaths_by integer not null default 0;
@@ -0,0 +1,2 @@
+alter table config
+    drop column friends_of_marked_threshold;
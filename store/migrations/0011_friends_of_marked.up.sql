@@ -0,0 +1,2 @@
+alter table config
+    add column friends_of_marked_threshold integer not null default 2 check ( friends_of_marked_threshold >= 0 );
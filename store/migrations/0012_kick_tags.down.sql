@@ -0,0 +1,2 @@
+alter table config
+    drop column kick_tags;
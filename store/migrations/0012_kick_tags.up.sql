@@ -0,0 +1,2 @@
+alter table config
+    add column kick_tags text not null default 'cheater';
@@ -0,0 +1,2 @@
+drop table if exists kick_vote_tags;
+drop table if exists kick_votes;
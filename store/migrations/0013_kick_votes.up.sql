@@ -0,0 +1,28 @@
+create table if not exists kick_votes
+(
+    kick_id         integer primary key,
+    target_steam_id integer not null,
+    target_name     text    not null default '',
+    reason          text    not null default '',
+    caller_steam_id integer not null default 0,
+    caller_name     text    not null default '',
+    ours            boolean not null default false,
+    server_name     text    not null default '',
+    address         text    not null default '',
+    start_time      date    not null,
+    end_time        date,
+    outcome         text    not null default 'pending',
+    message         text    not null default ''
+);
+
+create index if not exists idx_kick_votes_target_steam_id on kick_votes (target_steam_id);
+
+-- The attributes and lists the target was matched on when the vote was called.
+create table if not exists kick_vote_tags
+(
+    kick_id integer not null,
+    kind    text    not null,
+    value   text    not null,
+    foreign key (kick_id) references kick_votes (kick_id) on delete cascade,
+    primary key (kick_id, kind, value)
+);
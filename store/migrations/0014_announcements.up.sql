@@ -0,0 +1,15 @@
+-- Chat announcement templates for matched players. The severity of a match is the first enabled severity, in the
+-- order high, medium, low, sharing an attribute with it. A severity without attributes matches everything.
+create table if not exists announcements
+(
+    severity    text primary key check ( severity in ('high', 'medium', 'low') ),
+    attributes  text    not null default '',
+    destination text    not null default 'party' check ( destination in ('all', 'team', 'party') ),
+    template    text    not null,
+    enabled     boolean not null default true
+);
+
+insert into announcements (severity, attributes, destination, template)
+values ('high', 'cheater,bot', 'party', '({{.UserID}}) [{{.Origin}}] [{{join .Attributes ","}}] {{.Name}}'),
+       ('medium', 'suspicious,racist', 'party', '({{.UserID}}) [{{.Origin}}] [{{join .Attributes ","}}] {{.Name}}'),
+       ('low', '', 'party', '({{.UserID}}) [{{.Origin}}] [{{join .Attributes ","}}] {{.Name}}');
@@ -0,0 +1,7 @@
+drop table if exists auto_votes;
+
+alter table config
+    drop column auto_vote_protect_enabled;
+
+alter table config
+    drop column auto_vote_enabled;
@@ -0,0 +1,5 @@
+alter table config
+    add column votekick_abuse_match_enabled boolean not null default false;
+
+alter table config
+    add column votekick_abuse_announce_enabled boolean not null default false;
@@ -0,0 +1,2 @@
+alter table config
+    drop column rage_quit_round_window;
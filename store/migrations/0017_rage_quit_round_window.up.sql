@@ -0,0 +1,2 @@
+alter table config
+    add column rage_quit_round_window integer not null default 30 check ( rage_quit_round_window >= 0 );
@@ -0,0 +1,7 @@
+update kick_votes
+set outcome = 'passed'
+where outcome = 'target_left';
+
+update kick_votes
+set outcome = 'failed'
+where outcome = 'target_stayed';
@@ -0,0 +1,8 @@
+-- The vote result is not shown in the console, passed and failed were only ever inferred from the target leaving.
+update kick_votes
+set outcome = 'target_left'
+where outcome = 'passed';
+
+update kick_votes
+set outcome = 'target_stayed'
+where outcome = 'failed';
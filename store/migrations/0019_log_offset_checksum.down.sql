@@ -0,0 +1,2 @@
+alter table log_offset
+    drop column checksum;
@@ -0,0 +1,4 @@
+-- Checksum of the content preceding the saved offset, used to detect a log that was truncated and has since
+-- grown back past the saved offset.
+alter table log_offset
+    add column checksum integer not null default 0;
@@ -0,0 +1,5 @@
+alter table config
+    drop column votekick_abuse_announce_enabled;
+
+alter table config
+    drop column votekick_abuse_match_enabled;
@@ -0,0 +1,20 @@
+alter table config
+    add column auto_vote_enabled boolean not null default false;
+
+alter table config
+    add column auto_vote_protect_enabled boolean not null default false;
+
+-- Votes we cast automatically on kick votes called by other players.
+create table if not exists auto_votes
+(
+    auto_vote_id    integer primary key,
+    kick_id         integer,
+    target_steam_id integer not null,
+    target_name     text    not null default '',
+    option          text    not null,
+    reason          text    not null,
+    created_on      date    not null,
+    foreign key (kick_id) references kick_votes (kick_id) on delete set null
+);
+
+create index if not exists idx_auto_votes_target_steam_id on auto_votes (target_steam_id);
@@ -0,0 +1,51 @@
+create table events_new
+(
+    event_id        integer primary key,
+    session_id      text    not null,
+    event_type      integer not null,
+    player_name     text    not null default '',
+    steam_id        integer not null default 0,
+    victim_name     text    not null default '',
+    victim_steam_id integer not null default 0,
+    message         text    not null default '',
+    meta_data       text    not null default '',
+    team            integer not null default 0,
+    weapon          text    not null default '',
+    crit            boolean not null default false,
+    dead            boolean not null default false,
+    team_only       boolean not null default false,
+    created_on      date    not null
+);
+
+insert into events_new (event_id, session_id, event_type, player_name, steam_id, victim_name, victim_steam_id,
+                        message, meta_data, team, weapon, crit, dead, team_only, created_on)
+select event_id,
+       coalesce(cast(session_id as text), ''),
+       case event_type
+           when 'kill' then 0
+           when 'msg' then 1
+           when 'connect' then 2
+           when 'disconnect' then 3
+           when 'status_id' then 4
+           when 'hostname' then 5
+           when 'map' then 6
+           when 'tags' then 7
+           when 'address' then 8
+           when 'lobby' then 9
+           when 'version' then 10
+           when 'player_count' then 11
+           when 'control' then 12
+           when 'round_win' then 13
+           else -1 end,
+       player_name, steam_id, victim_name, victim_steam_id, message, meta_data, team, weapon, crit, dead,
+       team_only, created_on
+from events;
+
+drop table events;
+
+alter table events_new
+    rename to events;
+
+create index if not exists idx_events_created_on on events (created_on);
+create index if not exists idx_events_steam_id on events (steam_id);
+create index if not exists idx_events_victim_steam_id on events (victim_steam_id);
@@ -0,0 +1,54 @@
+-- Events reference the session they were recorded in and store the name of their type, the old random
+-- session ids cannot be matched to a session.
+create table events_new
+(
+    event_id        integer primary key,
+    session_id      integer references sessions (session_id) on delete set null,
+    event_type      text    not null,
+    player_name     text    not null default '',
+    steam_id        integer not null default 0,
+    victim_name     text    not null default '',
+    victim_steam_id integer not null default 0,
+    message         text    not null default '',
+    meta_data       text    not null default '',
+    team            integer not null default 0,
+    weapon          text    not null default '',
+    crit            boolean not null default false,
+    dead            boolean not null default false,
+    team_only       boolean not null default false,
+    created_on      date    not null
+);
+
+insert into events_new (event_id, session_id, event_type, player_name, steam_id, victim_name, victim_steam_id,
+                        message, meta_data, team, weapon, crit, dead, team_only, created_on)
+select event_id,
+       null,
+       case event_type
+           when 0 then 'kill'
+           when 1 then 'msg'
+           when 2 then 'connect'
+           when 3 then 'disconnect'
+           when 4 then 'status_id'
+           when 5 then 'hostname'
+           when 6 then 'map'
+           when 7 then 'tags'
+           when 8 then 'address'
+           when 9 then 'lobby'
+           when 10 then 'version'
+           when 11 then 'player_count'
+           when 12 then 'control'
+           when 13 then 'round_win'
+           else 'unknown' end,
+       player_name, steam_id, victim_name, victim_steam_id, message, meta_data, team, weapon, crit, dead,
+       team_only, created_on
+from events;
+
+drop table events;
+
+alter table events_new
+    rename to events;
+
+create index if not exists idx_events_created_on on events (created_on);
+create index if not exists idx_events_steam_id on events (steam_id);
+create index if not exists idx_events_victim_steam_id on events (victim_steam_id);
+create index if not exists idx_events_session_id on events (session_id);
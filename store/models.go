@@ -9,32 +9,91 @@
 	"time"
 )
 
+type Announcement struct {
+	Severity    string `json:"severity"`
+	Attributes  string `json:"attributes"`
+	Destination string `json:"destination"`
+	Template    string `json:"template"`
+	Enabled     bool   `json:"enabled"`
+}
+
 type Config struct {
-	SteamID                 string `json:"steam_id"`
-	SteamDir                string `json:"steam_dir"`
-	Tf2Dir                  string `json:"tf2_dir"`
-	AutoLaunchGame          bool   `json:"auto_launch_game"`
-	AutoCloseOnGameExit     bool   `json:"auto_close_on_game_exit"`
-	BdApiEnabled            bool   `json:"bd_api_enabled"`
-	BdApiAddress            string `json:"bd_api_address"`
-	ApiKey                  string `json:"api_key"`
-	SystrayEnabled          bool   `json:"systray_enabled"`
-	DisconnectedTimeout     int64  `json:"disconnected_timeout"`
-	DiscordPresenceEnabled  bool   `json:"discord_presence_enabled"`
-	KickerEnabled           bool   `json:"kicker_enabled"`
-	ChatWarningsEnabled     bool   `json:"chat_warnings_enabled"`
-	VoiceBansEnabled        bool   `json:"voice_bans_enabled"`
-	DebugLogEnabled         bool   `json:"debug_log_enabled"`
-	RconStatic              bool   `json:"rcon_static"`
-	HttpEnabled             bool   `json:"http_enabled"`
-	HttpListenAddr          string `json:"http_listen_addr"`
-	PlayerExpiredTimeout    int64  `json:"player_expired_timeout"`
-	PlayerDisconnectTimeout int64  `json:"player_disconnect_timeout"`
-	RunMode                 string `json:"run_mode"`
-	LogLevel                string `json:"log_level"`
-	RconAddress             string `json:"rcon_address"`
-	RconPort                int64  `json:"rcon_port"`
-	RconPassword            string `json:"rcon_password"`
+	SteamID                  string `json:"steam_id"`
+	SteamDir                 string `json:"steam_dir"`
+	Tf2Dir                   string `json:"tf2_dir"`
+	AutoLaunchGame           bool   `json:"auto_launch_game"`
+	AutoCloseOnGameExit      bool   `json:"auto_close_on_game_exit"`
+	BdApiEnabled             bool   `json:"bd_api_enabled"`
+	BdApiAddress             string `json:"bd_api_address"`
+	ApiKey                   string `json:"api_key"`
+	SystrayEnabled           bool   `json:"systray_enabled"`
+	DisconnectedTimeout      int64  `json:"disconnected_timeout"`
+	DiscordPresenceEnabled   bool   `json:"discord_presence_enabled"`
+	KickerEnabled            bool   `json:"kicker_enabled"`
+	ChatWarningsEnabled      bool   `json:"chat_warnings_enabled"`
+	VoiceBansEnabled         bool   `json:"voice_bans_enabled"`
+	DebugLogEnabled          bool   `json:"debug_log_enabled"`
+	RconStatic               bool   `json:"rcon_static"`
+	HttpEnabled              bool   `json:"http_enabled"`
+	HttpListenAddr           string `json:"http_listen_addr"`
+	PlayerExpiredTimeout     int64  `json:"player_expired_timeout"`
+	PlayerDisconnectTimeout  int64  `json:"player_disconnect_timeout"`
+	RunMode                  string `json:"run_mode"`
+	LogLevel                 string `json:"log_level"`
+	RconAddress              string `json:"rcon_address"`
+	RconPort                 int64  `json:"rcon_port"`
+	RconPassword             string `json:"rcon_password"`
+	RageQuitKillWindow       int64  `json:"rage_quit_kill_window"`
+	RageQuitVoteWindow       int64  `json:"rage_quit_vote_window"`
+	LogSource                string `json:"log_source"`
+	UdpListenAddr            string `json:"udp_listen_addr"`
+	UdpLogSecret             int64  `json:"udp_log_secret"`
+	EventJournalEnabled      bool   `json:"event_journal_enabled"`
+	EventJournalRetention    int64  `json:"event_journal_retention"`
+	EncounterRetention       int64  `json:"encounter_retention"`
+	FriendsOfMarkedThreshold int64  `json:"friends_of_marked_threshold"`
+	KickTags                 string `json:"kick_tags"`
+	RageQuitRoundWindow      int64  `json:"rage_quit_round_window"`
+}
+
+type Event struct {
+	EventID       int64         `json:"event_id"`
+	SessionID     sql.NullInt64 `json:"session_id"`
+	EventType     string        `json:"event_type"`
+	PlayerName    string        `json:"player_name"`
+	SteamID       int64         `json:"steam_id"`
+	VictimName    string        `json:"victim_name"`
+	VictimSteamID int64         `json:"victim_steam_id"`
+	Message       string        `json:"message"`
+	MetaData      string        `json:"meta_data"`
+	Team          int64         `json:"team"`
+	Weapon        string        `json:"weapon"`
+	Crit          bool          `json:"crit"`
+	Dead          bool          `json:"dead"`
+	TeamOnly      bool          `json:"team_only"`
+	CreatedOn     time.Time     `json:"created_on"`
+}
+
+type KickVoteTag struct {
+	KickID int64  `json:"kick_id"`
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+}
+
+type KickVote struct {
+	KickID        int64        `json:"kick_id"`
+	TargetSteamID int64        `json:"target_steam_id"`
+	TargetName    string       `json:"target_name"`
+	Reason        string       `json:"reason"`
+	CallerSteamID int64        `json:"caller_steam_id"`
+	CallerName    string       `json:"caller_name"`
+	Ours          bool         `json:"ours"`
+	ServerName    string       `json:"server_name"`
+	Address       string       `json:"address"`
+	StartTime     time.Time    `json:"start_time"`
+	EndTime       sql.NullTime `json:"end_time"`
+	Outcome       string       `json:"outcome"`
+	Message       string       `json:"message"`
 }
 
 type Link struct {
@@ -57,6 +116,16 @@ type List struct {
 	Name      string    `json:"name"`
 }
 
+type LogOffset struct {
+	Path       string    `json:"path"`
+	FileOffset int64     `json:"file_offset"`
+	FileSize   int64     `json:"file_size"`
+	Inode      int64     `json:"inode"`
+	ModTime    time.Time `json:"mod_time"`
+	UpdatedOn  time.Time `json:"updated_on"`
+	Checksum   int64     `json:"checksum"`
+}
+
 type Player struct {
 	SteamID          int64        `json:"steam_id"`
 	Personaname      string       `json:"personaname"`
@@ -78,6 +147,16 @@ type Player struct {
 	UpdatedOn        time.Time    `json:"updated_on"`
 }
 
+type PlayerEncounter struct {
+	SteamID       int64     `json:"steam_id"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+	PreviousSeen  time.Time `json:"previous_seen"`
+	TotalTime     int64     `json:"total_time"`
+	Sessions      int64     `json:"sessions"`
+	LastSessionID int64     `json:"last_session_id"`
+}
+
 type PlayerFriend struct {
 	SteamID       int64     `json:"steam_id"`
 	SteamIDFriend int64     `json:"steam_id_friend"`
@@ -92,6 +171,7 @@ type PlayerMessage struct {
 	Team      bool      `json:"team"`
 	Dead      bool      `json:"dead"`
 	CreatedOn time.Time `json:"created_on"`
+	Source    string    `json:"source"`
 }
 
 type PlayerName struct {
@@ -99,6 +179,7 @@ type PlayerName struct {
 	SteamID   int64     `json:"steam_id"`
 	Name      string    `json:"name"`
 	CreatedOn time.Time `json:"created_on"`
+	Source    string    `json:"source"`
 }
 
 type PlayerSourceban struct {
@@ -111,3 +192,24 @@ type PlayerSourceban struct {
 	Permanent    bool      `json:"permanent"`
 	CreatedOn    time.Time `json:"created_on"`
 }
+
+type SessionPlayer struct {
+	SessionID int64     `json:"session_id"`
+	SteamID   int64     `json:"steam_id"`
+	Name      string    `json:"name"`
+	Team      int64     `json:"team"`
+	Score     int64     `json:"score"`
+	UpdatedOn time.Time `json:"updated_on"`
+	KillsOn   int64     `json:"kills_on"`
+	DeathsBy  int64     `json:"deaths_by"`
+}
+
+type Session struct {
+	SessionID  int64        `json:"session_id"`
+	ServerName string       `json:"server_name"`
+	Address    string       `json:"address"`
+	MapName    string       `json:"map_name"`
+	Tags       string       `json:"tags"`
+	StartTime  time.Time    `json:"start_time"`
+	EndTime    sql.NullTime `json:"end_time"`
+}
@@ -6,14 +6,31 @@
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	AnnouncementUpdate(ctx context.Context, arg AnnouncementUpdateParams) error
+	Announcements(ctx context.Context) ([]Announcement, error)
 	Config(ctx context.Context) (Config, error)
 	ConfigUpdate(ctx context.Context, arg ConfigUpdateParams) error
+	Encounter(ctx context.Context, steamID int64) (PlayerEncounter, error)
+	EncounterSave(ctx context.Context, arg EncounterSaveParams) error
+	EncounterServers(ctx context.Context, steamID int64) ([]EncounterServersRow, error)
+	EncountersDeleteOlder(ctx context.Context, lastSeen time.Time) error
+	EventSave(ctx context.Context, arg EventSaveParams) error
+	Events(ctx context.Context, arg EventsParams) ([]Event, error)
+	EventsDeleteOlder(ctx context.Context, createdOn time.Time) error
 	Friends(ctx context.Context, steamID int64) ([]PlayerFriend, error)
 	FriendsDelete(ctx context.Context, steamID int64) error
 	FriendsInsert(ctx context.Context, arg FriendsInsertParams) error
+	KickVoteEnd(ctx context.Context, arg KickVoteEndParams) error
+	KickVoteInsert(ctx context.Context, arg KickVoteInsertParams) (KickVote, error)
+	KickVoteStatsByServer(ctx context.Context) ([]KickVoteStatsByServerRow, error)
+	KickVoteStatsByTag(ctx context.Context) ([]KickVoteStatsByTagRow, error)
+	KickVoteTagSave(ctx context.Context, arg KickVoteTagSaveParams) error
+	KickVotes(ctx context.Context, steamID interface{}) ([]KickVote, error)
+	KickVotesCloseOpen(ctx context.Context) error
 	Links(ctx context.Context) ([]LinksRow, error)
 	LinksDelete(ctx context.Context, linkID int64) error
 	LinksInsert(ctx context.Context, arg LinksInsertParams) (Link, error)
@@ -22,12 +39,26 @@ type Querier interface {
 	ListsDelete(ctx context.Context, listID int64) error
 	ListsInsert(ctx context.Context, arg ListsInsertParams) (List, error)
 	ListsUpdate(ctx context.Context, arg ListsUpdateParams) error
+	LogOffset(ctx context.Context, path string) (LogOffset, error)
+	LogOffsetSave(ctx context.Context, arg LogOffsetSaveParams) error
 	MessageSave(ctx context.Context, arg MessageSaveParams) error
 	Messages(ctx context.Context, steamID int64) ([]PlayerMessage, error)
 	Player(ctx context.Context, steamID int64) (PlayerRow, error)
 	PlayerInsert(ctx context.Context, arg PlayerInsertParams) (Player, error)
+	PlayerKillsAdd(ctx context.Context, arg PlayerKillsAddParams) error
 	PlayerSearch(ctx context.Context, arg PlayerSearchParams) ([]PlayerSearchRow, error)
+	PlayerSessionKills(ctx context.Context, steamID int64) ([]PlayerSessionKillsRow, error)
 	PlayerUpdate(ctx context.Context, arg PlayerUpdateParams) error
+	Session(ctx context.Context, sessionID int64) (Session, error)
+	SessionCoPlayers(ctx context.Context, arg SessionCoPlayersParams) ([]SessionCoPlayersRow, error)
+	SessionEnd(ctx context.Context, arg SessionEndParams) error
+	SessionInsert(ctx context.Context, arg SessionInsertParams) (Session, error)
+	SessionPlayerKillsAdd(ctx context.Context, arg SessionPlayerKillsAddParams) error
+	SessionPlayerSave(ctx context.Context, arg SessionPlayerSaveParams) error
+	SessionPlayers(ctx context.Context, sessionID int64) ([]SessionPlayer, error)
+	SessionUpdate(ctx context.Context, arg SessionUpdateParams) error
+	Sessions(ctx context.Context, steamID interface{}) ([]SessionsRow, error)
+	SessionsCloseOpen(ctx context.Context) error
 	Sourcebans(ctx context.Context, steamID int64) ([]PlayerSourceban, error)
 	SourcebansDelete(ctx context.Context, steamID int64) error
 	SourcebansInsert(ctx context.Context, arg SourcebansInsertParams) (PlayerSourceban, error)
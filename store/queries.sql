@@ -28,7 +28,18 @@ SET steam_id                  = @steam_id,
     log_level                 = @log_level,
     rcon_address              = @rcon_address,
     rcon_port                 = @rcon_port,
-    rcon_password             = @rcon_password;
+    rcon_password             = @rcon_password,
+    rage_quit_kill_window     = @rage_quit_kill_window,
+    rage_quit_vote_window     = @rage_quit_vote_window,
+    log_source                = @log_source,
+    udp_listen_addr           = @udp_listen_addr,
+    udp_log_secret            = @udp_log_secret,
+    event_journal_enabled     = @event_journal_enabled,
+    event_journal_retention   = @event_journal_retention,
+    encounter_retention       = @encounter_retention,
+    friends_of_marked_threshold = @friends_of_marked_threshold,
+    kick_tags                 = @kick_tags,
+    rage_quit_round_window = @rage_quit_round_window;
 
 -- name: Player :one
 SELECT p.steam_id,
@@ -106,20 +117,20 @@ ORDER BY p.updated_on DESC
 LIMIT 1000;
 
 -- name: UserNameSave :exec
-INSERT INTO player_names (name_id, steam_id, name, created_on)
+INSERT INTO player_names (steam_id, name, created_on, source)
 VALUES (?, ?, ?, ?);
 
 -- name: UserNames :many
-SELECT name_id, steam_id, name, created_on
+SELECT name_id, steam_id, name, created_on, source
 FROM player_names
 WHERE steam_id = @steam_id;
 
 -- name: MessageSave :exec
-INSERT INTO player_messages (steam_id, message, team, dead, created_on)
-VALUES (?, ?, ?, ?, ?);
+INSERT INTO player_messages (steam_id, message, team, dead, created_on, source)
+VALUES (?, ?, ?, ?, ?, ?);
 
 -- name: Messages :many
-SELECT message_id, steam_id, message, team, dead, created_on
+SELECT message_id, steam_id, message, team, dead, created_on, source
 FROM player_messages
 WHERE steam_id = @steam_id;
 
@@ -210,4 +221,239 @@ SELECT sourcebans_id,
        permanent,
        created_on
 FROM player_sourcebans
-WHERE steam_id = @steam_id;
\ No newline at end of file
+WHERE steam_id = @steam_id;
+-- name: LogOffset :one
+SELECT path, file_offset, file_size, inode, mod_time, updated_on, checksum
+FROM log_offset
+WHERE path = @path;
+
+-- name: LogOffsetSave :exec
+INSERT INTO log_offset (path, file_offset, file_size, inode, mod_time, updated_on, checksum)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (path) DO UPDATE SET file_offset = excluded.file_offset,
+                                 file_size   = excluded.file_size,
+                                 inode       = excluded.inode,
+                                 mod_time    = excluded.mod_time,
+                                 updated_on  = excluded.updated_on,
+                                 checksum    = excluded.checksum;
+
+-- name: EventSave :exec
+INSERT INTO events (session_id, event_type, player_name, steam_id, victim_name, victim_steam_id, message,
+                    meta_data, team, weapon, crit, dead, team_only, created_on)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: Events :many
+SELECT event_id,
+       session_id,
+       event_type,
+       player_name,
+       steam_id,
+       victim_name,
+       victim_steam_id,
+       message,
+       meta_data,
+       team,
+       weapon,
+       crit,
+       dead,
+       team_only,
+       created_on
+FROM events
+WHERE created_on >= @start_time
+  AND created_on <= @end_time
+  AND (@event_type = '' OR event_type = @event_type)
+  AND (@steam_id = 0 OR steam_id = @steam_id OR victim_steam_id = @steam_id)
+ORDER BY created_on DESC
+LIMIT 1000;
+
+-- name: EventsDeleteOlder :exec
+DELETE
+FROM events
+WHERE created_on < @created_on;
+
+-- name: SessionInsert :one
+INSERT INTO sessions (server_name, address, map_name, tags, start_time)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: SessionUpdate :exec
+UPDATE sessions
+SET server_name = @server_name,
+    address     = @address,
+    map_name    = @map_name,
+    tags        = @tags
+WHERE session_id = @session_id;
+
+-- name: SessionEnd :exec
+UPDATE sessions
+SET end_time = @end_time
+WHERE session_id = @session_id;
+
+-- name: SessionsCloseOpen :exec
+UPDATE sessions
+SET end_time = coalesce((SELECT max(sp.updated_on) FROM session_players sp WHERE sp.session_id = sessions.session_id),
+                        start_time)
+WHERE end_time IS NULL;
+
+-- name: Session :one
+SELECT session_id, server_name, address, map_name, tags, start_time, end_time
+FROM sessions
+WHERE session_id = @session_id;
+
+-- name: Sessions :many
+SELECT s.session_id,
+       s.server_name,
+       s.address,
+       s.map_name,
+       s.tags,
+       s.start_time,
+       s.end_time,
+       (SELECT count(*) FROM session_players sp WHERE sp.session_id = s.session_id) AS player_count
+FROM sessions s
+WHERE (@steam_id = 0 OR EXISTS (SELECT 1
+                                FROM session_players sp
+                                WHERE sp.session_id = s.session_id
+                                  AND sp.steam_id = @steam_id))
+ORDER BY s.start_time DESC
+LIMIT 100;
+
+-- name: SessionPlayerSave :exec
+INSERT INTO session_players (session_id, steam_id, name, team, score, updated_on)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (session_id, steam_id) DO UPDATE SET name       = excluded.name,
+                                                 team       = excluded.team,
+                                                 score      = excluded.score,
+                                                 updated_on = excluded.updated_on;
+
+-- name: SessionPlayers :many
+SELECT session_id, steam_id, name, team, score, updated_on, kills_on, deaths_by
+FROM session_players
+WHERE session_id = @session_id
+ORDER BY team, score DESC;
+
+-- name: SessionPlayerKillsAdd :exec
+INSERT INTO session_players (session_id, steam_id, kills_on, deaths_by, updated_on)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (session_id, steam_id) DO UPDATE SET kills_on   = session_players.kills_on + excluded.kills_on,
+                                                 deaths_by  = session_players.deaths_by + excluded.deaths_by,
+                                                 updated_on = excluded.updated_on;
+
+-- name: PlayerKillsAdd :exec
+UPDATE player
+SET kills_on  = kills_on + @kills_on,
+    deaths_by = deaths_by + @deaths_by
+WHERE steam_id = @steam_id;
+
+-- name: PlayerSessionKills :many
+SELECT s.session_id, s.server_name, s.map_name, s.start_time, sp.kills_on, sp.deaths_by
+FROM session_players sp
+         INNER JOIN sessions s ON s.session_id = sp.session_id
+WHERE sp.steam_id = @steam_id
+  AND (sp.kills_on > 0 OR sp.deaths_by > 0)
+ORDER BY s.start_time DESC
+LIMIT 100;
+
+-- name: Encounter :one
+SELECT steam_id, first_seen, last_seen, previous_seen, total_time, sessions, last_session_id
+FROM player_encounters
+WHERE steam_id = @steam_id;
+
+-- name: EncounterSave :exec
+INSERT INTO player_encounters (steam_id, first_seen, last_seen, previous_seen, total_time, sessions, last_session_id)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (steam_id) DO UPDATE SET last_seen       = excluded.last_seen,
+                                     previous_seen   = excluded.previous_seen,
+                                     total_time      = excluded.total_time,
+                                     sessions        = excluded.sessions,
+                                     last_session_id = excluded.last_session_id;
+
+-- name: EncountersDeleteOlder :exec
+DELETE
+FROM player_encounters
+WHERE last_seen < @last_seen;
+
+-- name: EncounterServers :many
+SELECT s.server_name, s.address, count(*) AS sessions
+FROM session_players sp
+         INNER JOIN sessions s ON s.session_id = sp.session_id
+WHERE sp.steam_id = @steam_id
+GROUP BY s.server_name, s.address
+ORDER BY max(s.start_time) DESC;
+
+-- name: SessionCoPlayers :many
+SELECT b.steam_id, count(*) AS sessions
+FROM session_players a
+         INNER JOIN session_players b ON b.session_id = a.session_id AND b.steam_id != a.steam_id
+WHERE a.steam_id = @steam_id
+  AND a.session_id != @session_id
+GROUP BY b.steam_id
+HAVING count(*) >= @min_sessions;
+
+-- name: KickVoteInsert :one
+INSERT INTO kick_votes (target_steam_id, target_name, reason, caller_steam_id, caller_name, ours, server_name, address,
+                        start_time, end_time, outcome, message)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: KickVoteTagSave :exec
+INSERT INTO kick_vote_tags (kick_id, kind, value)
+VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: KickVoteEnd :exec
+UPDATE kick_votes
+SET outcome  = @outcome,
+    end_time = @end_time
+WHERE kick_id = @kick_id
+  AND outcome = 'pending';
+
+-- name: KickVotesCloseOpen :exec
+UPDATE kick_votes
+SET outcome  = 'cancelled',
+    end_time = start_time
+WHERE outcome = 'pending';
+
+-- name: KickVotes :many
+SELECT *
+FROM kick_votes
+WHERE (@steam_id = 0 OR target_steam_id = @steam_id OR caller_steam_id = @steam_id)
+ORDER BY start_time DESC
+LIMIT 100;
+
+-- name: KickVoteStatsByTag :many
+SELECT t.kind,
+       t.value,
+       count(*)                                             AS total,
+       count(CASE WHEN k.outcome = 'target_left' THEN 1 END)   AS target_left,
+       count(CASE WHEN k.outcome = 'target_stayed' THEN 1 END) AS target_stayed,
+       count(CASE WHEN k.outcome = 'cancelled' THEN 1 END) AS cancelled,
+       count(CASE WHEN k.outcome = 'rejected' THEN 1 END)  AS rejected
+FROM kick_vote_tags t
+         INNER JOIN kick_votes k ON k.kick_id = t.kick_id
+GROUP BY t.kind, t.value
+ORDER BY total DESC;
+
+-- name: KickVoteStatsByServer :many
+SELECT server_name,
+       address,
+       count(*)                                           AS total,
+       count(CASE WHEN outcome = 'target_left' THEN 1 END)   AS target_left,
+       count(CASE WHEN outcome = 'target_stayed' THEN 1 END) AS target_stayed,
+       count(CASE WHEN outcome = 'cancelled' THEN 1 END) AS cancelled,
+       count(CASE WHEN outcome = 'rejected' THEN 1 END)  AS rejected
+FROM kick_votes
+GROUP BY server_name, address
+ORDER BY total DESC;
+
+-- name: Announcements :many
+SELECT *
+FROM announcements
+ORDER BY CASE severity WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END;
+
+-- name: AnnouncementUpdate :exec
+UPDATE announcements
+SET attributes  = @attributes,
+    destination = @destination,
+    template    = @template,
+    enabled     = @enabled
+WHERE severity = @severity;
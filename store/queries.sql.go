@@ -11,8 +11,71 @@
 	"time"
 )
 
+const announcementUpdate = `-- name: AnnouncementUpdate :exec
+UPDATE announcements
+SET attributes  = ?1,
+    destination = ?2,
+    template    = ?3,
+    enabled     = ?4
+WHERE severity = ?5
+`
+
+type AnnouncementUpdateParams struct {
+	Attributes  string `json:"attributes"`
+	Destination string `json:"destination"`
+	Template    string `json:"template"`
+	Enabled     bool   `json:"enabled"`
+	Severity    string `json:"severity"`
+}
+
+func (q *Queries) AnnouncementUpdate(ctx context.Context, arg AnnouncementUpdateParams) error {
+	_, err := q.exec(ctx, q.announcementUpdateStmt, announcementUpdate,
+		arg.Attributes,
+		arg.Destination,
+		arg.Template,
+		arg.Enabled,
+		arg.Severity,
+	)
+	return err
+}
+
+const announcements = `-- name: Announcements :many
+SELECT severity, attributes, destination, template, enabled
+FROM announcements
+ORDER BY CASE severity WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END
+`
+
+func (q *Queries) Announcements(ctx context.Context) ([]Announcement, error) {
+	rows, err := q.query(ctx, q.announcementsStmt, announcements)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Announcement
+	for rows.Next() {
+		var i Announcement
+		if err := rows.Scan(
+			&i.Severity,
+			&i.Attributes,
+			&i.Destination,
+			&i.Template,
+			&i.Enabled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const config = `-- name: Config :one
-SELECT steam_id, steam_dir, tf2_dir, auto_launch_game, auto_close_on_game_exit, bd_api_enabled, bd_api_address, api_key, systray_enabled, disconnected_timeout, discord_presence_enabled, kicker_enabled, chat_warnings_enabled, voice_bans_enabled, debug_log_enabled, rcon_static, http_enabled, http_listen_addr, player_expired_timeout, player_disconnect_timeout, run_mode, log_level, rcon_address, rcon_port, rcon_password
+SELECT steam_id, steam_dir, tf2_dir, auto_launch_game, auto_close_on_game_exit, bd_api_enabled, bd_api_address, api_key, systray_enabled, disconnected_timeout, discord_presence_enabled, kicker_enabled, chat_warnings_enabled, voice_bans_enabled, debug_log_enabled, rcon_static, http_enabled, http_listen_addr, player_expired_timeout, player_disconnect_timeout, run_mode, log_level, rcon_address, rcon_port, rcon_password, rage_quit_kill_window, rage_quit_vote_window, log_source, udp_listen_addr, udp_log_secret, event_journal_enabled, event_journal_retention, encounter_retention, friends_of_marked_threshold, kick_tags, rage_quit_round_window
 FROM config
 `
 
@@ -45,6 +108,17 @@ func (q *Queries) Config(ctx context.Context) (Config, error) {
 		&i.RconAddress,
 		&i.RconPort,
 		&i.RconPassword,
+		&i.RageQuitKillWindow,
+		&i.RageQuitVoteWindow,
+		&i.LogSource,
+		&i.UdpListenAddr,
+		&i.UdpLogSecret,
+		&i.EventJournalEnabled,
+		&i.EventJournalRetention,
+		&i.EncounterRetention,
+		&i.FriendsOfMarkedThreshold,
+		&i.KickTags,
+		&i.RageQuitRoundWindow,
 	)
 	return i, err
 }
@@ -75,35 +149,57 @@ func (q *Queries) Config(ctx context.Context) (Config, error) {
     log_level                 = ?22,
     rcon_address              = ?23,
     rcon_port                 = ?24,
-    rcon_password             = ?25
+    rcon_password             = ?25,
+    rage_quit_kill_window     = ?26,
+    rage_quit_vote_window     = ?27,
+    log_source                = ?28,
+    udp_listen_addr           = ?29,
+    udp_log_secret            = ?30,
+    event_journal_enabled     = ?31,
+    event_journal_retention   = ?32,
+    encounter_retention       = ?33,
+    friends_of_marked_threshold = ?34,
+    kick_tags                 = ?35,
+    rage_quit_round_window = ?36
 `
 
 type ConfigUpdateParams struct {
-	SteamID                 string `json:"steam_id"`
-	SteamDir                string `json:"steam_dir"`
-	Tf2Dir                  string `json:"tf2_dir"`
-	AutoLaunchGame          bool   `json:"auto_launch_game"`
-	AutoCloseOnGameExit     bool   `json:"auto_close_on_game_exit"`
-	BdApiEnabled            bool   `json:"bd_api_enabled"`
-	BdApiAddress            string `json:"bd_api_address"`
-	ApiKey                  string `json:"api_key"`
-	SystrayEnabled          bool   `json:"systray_enabled"`
-	DisconnectedTimeout     int64  `json:"disconnected_timeout"`
-	DiscordPresenceEnabled  bool   `json:"discord_presence_enabled"`
-	KickerEnabled           bool   `json:"kicker_enabled"`
-	ChatWarningsEnabled     bool   `json:"chat_warnings_enabled"`
-	VoiceBansEnabled        bool   `json:"voice_bans_enabled"`
-	DebugLogEnabled         bool   `json:"debug_log_enabled"`
-	RconStatic              bool   `json:"rcon_static"`
-	HttpEnabled             bool   `json:"http_enabled"`
-	HttpListenAddr          string `json:"http_listen_addr"`
-	PlayerExpiredTimeout    int64  `json:"player_expired_timeout"`
-	PlayerDisconnectTimeout int64  `json:"player_disconnect_timeout"`
-	RunMode                 string `json:"run_mode"`
-	LogLevel                string `json:"log_level"`
-	RconAddress             string `json:"rcon_address"`
-	RconPort                int64  `json:"rcon_port"`
-	RconPassword            string `json:"rcon_password"`
+	SteamID                  string `json:"steam_id"`
+	SteamDir                 string `json:"steam_dir"`
+	Tf2Dir                   string `json:"tf2_dir"`
+	AutoLaunchGame           bool   `json:"auto_launch_game"`
+	AutoCloseOnGameExit      bool   `json:"auto_close_on_game_exit"`
+	BdApiEnabled             bool   `json:"bd_api_enabled"`
+	BdApiAddress             string `json:"bd_api_address"`
+	ApiKey                   string `json:"api_key"`
+	SystrayEnabled           bool   `json:"systray_enabled"`
+	DisconnectedTimeout      int64  `json:"disconnected_timeout"`
+	DiscordPresenceEnabled   bool   `json:"discord_presence_enabled"`
+	KickerEnabled            bool   `json:"kicker_enabled"`
+	ChatWarningsEnabled      bool   `json:"chat_warnings_enabled"`
+	VoiceBansEnabled         bool   `json:"voice_bans_enabled"`
+	DebugLogEnabled          bool   `json:"debug_log_enabled"`
+	RconStatic               bool   `json:"rcon_static"`
+	HttpEnabled              bool   `json:"http_enabled"`
+	HttpListenAddr           string `json:"http_listen_addr"`
+	PlayerExpiredTimeout     int64  `json:"player_expired_timeout"`
+	PlayerDisconnectTimeout  int64  `json:"player_disconnect_timeout"`
+	RunMode                  string `json:"run_mode"`
+	LogLevel                 string `json:"log_level"`
+	RconAddress              string `json:"rcon_address"`
+	RconPort                 int64  `json:"rcon_port"`
+	RconPassword             string `json:"rcon_password"`
+	RageQuitKillWindow       int64  `json:"rage_quit_kill_window"`
+	RageQuitVoteWindow       int64  `json:"rage_quit_vote_window"`
+	LogSource                string `json:"log_source"`
+	UdpListenAddr            string `json:"udp_listen_addr"`
+	UdpLogSecret             int64  `json:"udp_log_secret"`
+	EventJournalEnabled      bool   `json:"event_journal_enabled"`
+	EventJournalRetention    int64  `json:"event_journal_retention"`
+	EncounterRetention       int64  `json:"encounter_retention"`
+	FriendsOfMarkedThreshold int64  `json:"friends_of_marked_threshold"`
+	KickTags                 string `json:"kick_tags"`
+	RageQuitRoundWindow      int64  `json:"rage_quit_round_window"`
 }
 
 func (q *Queries) ConfigUpdate(ctx context.Context, arg ConfigUpdateParams) error {
@@ -133,10 +229,254 @@ func (q *Queries) ConfigUpdate(ctx context.Context, arg ConfigUpdateParams) erro
 		arg.RconAddress,
 		arg.RconPort,
 		arg.RconPassword,
+		arg.RageQuitKillWindow,
+		arg.RageQuitVoteWindow,
+		arg.LogSource,
+		arg.UdpListenAddr,
+		arg.UdpLogSecret,
+		arg.EventJournalEnabled,
+		arg.EventJournalRetention,
+		arg.EncounterRetention,
+		arg.FriendsOfMarkedThreshold,
+		arg.KickTags,
+		arg.RageQuitRoundWindow,
+	)
+	return err
+}
+
+const encounter = `-- name: Encounter :one
+SELECT steam_id, first_seen, last_seen, previous_seen, total_time, sessions, last_session_id
+FROM player_encounters
+WHERE steam_id = ?1
+`
+
+func (q *Queries) Encounter(ctx context.Context, steamID int64) (PlayerEncounter, error) {
+	row := q.queryRow(ctx, q.encounterStmt, encounter, steamID)
+	var i PlayerEncounter
+	err := row.Scan(
+		&i.SteamID,
+		&i.FirstSeen,
+		&i.LastSeen,
+		&i.PreviousSeen,
+		&i.TotalTime,
+		&i.Sessions,
+		&i.LastSessionID,
+	)
+	return i, err
+}
+
+const encounterSave = `-- name: EncounterSave :exec
+INSERT INTO player_encounters (steam_id, first_seen, last_seen, previous_seen, total_time, sessions, last_session_id)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (steam_id) DO UPDATE SET last_seen       = excluded.last_seen,
+                                     previous_seen   = excluded.previous_seen,
+                                     total_time      = excluded.total_time,
+                                     sessions        = excluded.sessions,
+                                     last_session_id = excluded.last_session_id
+`
+
+type EncounterSaveParams struct {
+	SteamID       int64     `json:"steam_id"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+	PreviousSeen  time.Time `json:"previous_seen"`
+	TotalTime     int64     `json:"total_time"`
+	Sessions      int64     `json:"sessions"`
+	LastSessionID int64     `json:"last_session_id"`
+}
+
+func (q *Queries) EncounterSave(ctx context.Context, arg EncounterSaveParams) error {
+	_, err := q.exec(ctx, q.encounterSaveStmt, encounterSave,
+		arg.SteamID,
+		arg.FirstSeen,
+		arg.LastSeen,
+		arg.PreviousSeen,
+		arg.TotalTime,
+		arg.Sessions,
+		arg.LastSessionID,
+	)
+	return err
+}
+
+const encounterServers = `-- name: EncounterServers :many
+SELECT s.server_name, s.address, count(*) AS sessions
+FROM session_players sp
+         INNER JOIN sessions s ON s.session_id = sp.session_id
+WHERE sp.steam_id = ?1
+GROUP BY s.server_name, s.address
+ORDER BY max(s.start_time) DESC
+`
+
+type EncounterServersRow struct {
+	ServerName string `json:"server_name"`
+	Address    string `json:"address"`
+	Sessions   int64  `json:"sessions"`
+}
+
+func (q *Queries) EncounterServers(ctx context.Context, steamID int64) ([]EncounterServersRow, error) {
+	rows, err := q.query(ctx, q.encounterServersStmt, encounterServers, steamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EncounterServersRow
+	for rows.Next() {
+		var i EncounterServersRow
+		if err := rows.Scan(&i.ServerName, &i.Address, &i.Sessions); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const encountersDeleteOlder = `-- name: EncountersDeleteOlder :exec
+DELETE
+FROM player_encounters
+WHERE last_seen < ?1
+`
+
+func (q *Queries) EncountersDeleteOlder(ctx context.Context, lastSeen time.Time) error {
+	_, err := q.exec(ctx, q.encountersDeleteOlderStmt, encountersDeleteOlder, lastSeen)
+	return err
+}
+
+const eventSave = `-- name: EventSave :exec
+INSERT INTO events (session_id, event_type, player_name, steam_id, victim_name, victim_steam_id, message,
+                    meta_data, team, weapon, crit, dead, team_only, created_on)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type EventSaveParams struct {
+	SessionID     sql.NullInt64 `json:"session_id"`
+	EventType     string        `json:"event_type"`
+	PlayerName    string        `json:"player_name"`
+	SteamID       int64         `json:"steam_id"`
+	VictimName    string        `json:"victim_name"`
+	VictimSteamID int64         `json:"victim_steam_id"`
+	Message       string        `json:"message"`
+	MetaData      string        `json:"meta_data"`
+	Team          int64         `json:"team"`
+	Weapon        string        `json:"weapon"`
+	Crit          bool          `json:"crit"`
+	Dead          bool          `json:"dead"`
+	TeamOnly      bool          `json:"team_only"`
+	CreatedOn     time.Time     `json:"created_on"`
+}
+
+func (q *Queries) EventSave(ctx context.Context, arg EventSaveParams) error {
+	_, err := q.exec(ctx, q.eventSaveStmt, eventSave,
+		arg.SessionID,
+		arg.EventType,
+		arg.PlayerName,
+		arg.SteamID,
+		arg.VictimName,
+		arg.VictimSteamID,
+		arg.Message,
+		arg.MetaData,
+		arg.Team,
+		arg.Weapon,
+		arg.Crit,
+		arg.Dead,
+		arg.TeamOnly,
+		arg.CreatedOn,
 	)
 	return err
 }
 
+const events = `-- name: Events :many
+SELECT event_id,
+       session_id,
+       event_type,
+       player_name,
+       steam_id,
+       victim_name,
+       victim_steam_id,
+       message,
+       meta_data,
+       team,
+       weapon,
+       crit,
+       dead,
+       team_only,
+       created_on
+FROM events
+WHERE created_on >= ?1
+  AND created_on <= ?2
+  AND (?3 = '' OR event_type = ?3)
+  AND (?4 = 0 OR steam_id = ?4 OR victim_steam_id = ?4)
+ORDER BY created_on DESC
+LIMIT 1000
+`
+
+type EventsParams struct {
+	StartTime time.Time   `json:"start_time"`
+	EndTime   time.Time   `json:"end_time"`
+	EventType interface{} `json:"event_type"`
+	SteamID   interface{} `json:"steam_id"`
+}
+
+func (q *Queries) Events(ctx context.Context, arg EventsParams) ([]Event, error) {
+	rows, err := q.query(ctx, q.eventsStmt, events,
+		arg.StartTime,
+		arg.EndTime,
+		arg.EventType,
+		arg.SteamID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.EventID,
+			&i.SessionID,
+			&i.EventType,
+			&i.PlayerName,
+			&i.SteamID,
+			&i.VictimName,
+			&i.VictimSteamID,
+			&i.Message,
+			&i.MetaData,
+			&i.Team,
+			&i.Weapon,
+			&i.Crit,
+			&i.Dead,
+			&i.TeamOnly,
+			&i.CreatedOn,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const eventsDeleteOlder = `-- name: EventsDeleteOlder :exec
+DELETE
+FROM events
+WHERE created_on < ?1
+`
+
+func (q *Queries) EventsDeleteOlder(ctx context.Context, createdOn time.Time) error {
+	_, err := q.exec(ctx, q.eventsDeleteOlderStmt, eventsDeleteOlder, createdOn)
+	return err
+}
+
 const friends = `-- name: Friends :many
 SELECT steam_id, steam_id_friend, friend_since, created_on
 FROM player_friends
@@ -204,6 +544,264 @@ func (q *Queries) FriendsInsert(ctx context.Context, arg FriendsInsertParams) er
 	return err
 }
 
+const kickVoteEnd = `-- name: KickVoteEnd :exec
+UPDATE kick_votes
+SET outcome  = ?1,
+    end_time = ?2
+WHERE kick_id = ?3
+  AND outcome = 'pending'
+`
+
+type KickVoteEndParams struct {
+	Outcome string       `json:"outcome"`
+	EndTime sql.NullTime `json:"end_time"`
+	KickID  int64        `json:"kick_id"`
+}
+
+func (q *Queries) KickVoteEnd(ctx context.Context, arg KickVoteEndParams) error {
+	_, err := q.exec(ctx, q.kickVoteEndStmt, kickVoteEnd, arg.Outcome, arg.EndTime, arg.KickID)
+	return err
+}
+
+const kickVoteInsert = `-- name: KickVoteInsert :one
+INSERT INTO kick_votes (target_steam_id, target_name, reason, caller_steam_id, caller_name, ours, server_name, address,
+                        start_time, end_time, outcome, message)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING kick_id, target_steam_id, target_name, reason, caller_steam_id, caller_name, ours, server_name, address, start_time, end_time, outcome, message
+`
+
+type KickVoteInsertParams struct {
+	TargetSteamID int64        `json:"target_steam_id"`
+	TargetName    string       `json:"target_name"`
+	Reason        string       `json:"reason"`
+	CallerSteamID int64        `json:"caller_steam_id"`
+	CallerName    string       `json:"caller_name"`
+	Ours          bool         `json:"ours"`
+	ServerName    string       `json:"server_name"`
+	Address       string       `json:"address"`
+	StartTime     time.Time    `json:"start_time"`
+	EndTime       sql.NullTime `json:"end_time"`
+	Outcome       string       `json:"outcome"`
+	Message       string       `json:"message"`
+}
+
+func (q *Queries) KickVoteInsert(ctx context.Context, arg KickVoteInsertParams) (KickVote, error) {
+	row := q.queryRow(ctx, q.kickVoteInsertStmt, kickVoteInsert,
+		arg.TargetSteamID,
+		arg.TargetName,
+		arg.Reason,
+		arg.CallerSteamID,
+		arg.CallerName,
+		arg.Ours,
+		arg.ServerName,
+		arg.Address,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Outcome,
+		arg.Message,
+	)
+	var i KickVote
+	err := row.Scan(
+		&i.KickID,
+		&i.TargetSteamID,
+		&i.TargetName,
+		&i.Reason,
+		&i.CallerSteamID,
+		&i.CallerName,
+		&i.Ours,
+		&i.ServerName,
+		&i.Address,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Outcome,
+		&i.Message,
+	)
+	return i, err
+}
+
+const kickVoteStatsByServer = `-- name: KickVoteStatsByServer :many
+SELECT server_name,
+       address,
+       count(*)                                           AS total,
+       count(CASE WHEN outcome = 'target_left' THEN 1 END)   AS target_left,
+       count(CASE WHEN outcome = 'target_stayed' THEN 1 END) AS target_stayed,
+       count(CASE WHEN outcome = 'cancelled' THEN 1 END) AS cancelled,
+       count(CASE WHEN outcome = 'rejected' THEN 1 END)  AS rejected
+FROM kick_votes
+GROUP BY server_name, address
+ORDER BY total DESC
+`
+
+type KickVoteStatsByServerRow struct {
+	ServerName   string `json:"server_name"`
+	Address      string `json:"address"`
+	Total        int64  `json:"total"`
+	TargetLeft   int64  `json:"target_left"`
+	TargetStayed int64  `json:"target_stayed"`
+	Cancelled    int64  `json:"cancelled"`
+	Rejected     int64  `json:"rejected"`
+}
+
+func (q *Queries) KickVoteStatsByServer(ctx context.Context) ([]KickVoteStatsByServerRow, error) {
+	rows, err := q.query(ctx, q.kickVoteStatsByServerStmt, kickVoteStatsByServer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KickVoteStatsByServerRow
+	for rows.Next() {
+		var i KickVoteStatsByServerRow
+		if err := rows.Scan(
+			&i.ServerName,
+			&i.Address,
+			&i.Total,
+			&i.TargetLeft,
+			&i.TargetStayed,
+			&i.Cancelled,
+			&i.Rejected,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const kickVoteStatsByTag = `-- name: KickVoteStatsByTag :many
+SELECT t.kind,
+       t.value,
+       count(*)                                             AS total,
+       count(CASE WHEN k.outcome = 'target_left' THEN 1 END)   AS target_left,
+       count(CASE WHEN k.outcome = 'target_stayed' THEN 1 END) AS target_stayed,
+       count(CASE WHEN k.outcome = 'cancelled' THEN 1 END) AS cancelled,
+       count(CASE WHEN k.outcome = 'rejected' THEN 1 END)  AS rejected
+FROM kick_vote_tags t
+         INNER JOIN kick_votes k ON k.kick_id = t.kick_id
+GROUP BY t.kind, t.value
+ORDER BY total DESC
+`
+
+type KickVoteStatsByTagRow struct {
+	Kind         string `json:"kind"`
+	Value        string `json:"value"`
+	Total        int64  `json:"total"`
+	TargetLeft   int64  `json:"target_left"`
+	TargetStayed int64  `json:"target_stayed"`
+	Cancelled    int64  `json:"cancelled"`
+	Rejected     int64  `json:"rejected"`
+}
+
+func (q *Queries) KickVoteStatsByTag(ctx context.Context) ([]KickVoteStatsByTagRow, error) {
+	rows, err := q.query(ctx, q.kickVoteStatsByTagStmt, kickVoteStatsByTag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KickVoteStatsByTagRow
+	for rows.Next() {
+		var i KickVoteStatsByTagRow
+		if err := rows.Scan(
+			&i.Kind,
+			&i.Value,
+			&i.Total,
+			&i.TargetLeft,
+			&i.TargetStayed,
+			&i.Cancelled,
+			&i.Rejected,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const kickVoteTagSave = `-- name: KickVoteTagSave :exec
+INSERT INTO kick_vote_tags (kick_id, kind, value)
+VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type KickVoteTagSaveParams struct {
+	KickID int64  `json:"kick_id"`
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+}
+
+func (q *Queries) KickVoteTagSave(ctx context.Context, arg KickVoteTagSaveParams) error {
+	_, err := q.exec(ctx, q.kickVoteTagSaveStmt, kickVoteTagSave, arg.KickID, arg.Kind, arg.Value)
+	return err
+}
+
+const kickVotes = `-- name: KickVotes :many
+SELECT kick_id, target_steam_id, target_name, reason, caller_steam_id, caller_name, ours, server_name, address, start_time, end_time, outcome, message
+FROM kick_votes
+WHERE (?1 = 0 OR target_steam_id = ?1 OR caller_steam_id = ?1)
+ORDER BY start_time DESC
+LIMIT 100
+`
+
+func (q *Queries) KickVotes(ctx context.Context, steamID interface{}) ([]KickVote, error) {
+	rows, err := q.query(ctx, q.kickVotesStmt, kickVotes, steamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KickVote
+	for rows.Next() {
+		var i KickVote
+		if err := rows.Scan(
+			&i.KickID,
+			&i.TargetSteamID,
+			&i.TargetName,
+			&i.Reason,
+			&i.CallerSteamID,
+			&i.CallerName,
+			&i.Ours,
+			&i.ServerName,
+			&i.Address,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Outcome,
+			&i.Message,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const kickVotesCloseOpen = `-- name: KickVotesCloseOpen :exec
+UPDATE kick_votes
+SET outcome  = 'cancelled',
+    end_time = start_time
+WHERE outcome = 'pending'
+`
+
+func (q *Queries) KickVotesCloseOpen(ctx context.Context) error {
+	_, err := q.exec(ctx, q.kickVotesCloseOpenStmt, kickVotesCloseOpen)
+	return err
+}
+
 const links = `-- name: Links :many
 SELECT link_id,
        name,
@@ -461,9 +1059,64 @@ func (q *Queries) ListsUpdate(ctx context.Context, arg ListsUpdateParams) error
 	return err
 }
 
+const logOffset = `-- name: LogOffset :one
+SELECT path, file_offset, file_size, inode, mod_time, updated_on, checksum
+FROM log_offset
+WHERE path = ?1
+`
+
+func (q *Queries) LogOffset(ctx context.Context, path string) (LogOffset, error) {
+	row := q.queryRow(ctx, q.logOffsetStmt, logOffset, path)
+	var i LogOffset
+	err := row.Scan(
+		&i.Path,
+		&i.FileOffset,
+		&i.FileSize,
+		&i.Inode,
+		&i.ModTime,
+		&i.UpdatedOn,
+		&i.Checksum,
+	)
+	return i, err
+}
+
+const logOffsetSave = `-- name: LogOffsetSave :exec
+INSERT INTO log_offset (path, file_offset, file_size, inode, mod_time, updated_on, checksum)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (path) DO UPDATE SET file_offset = excluded.file_offset,
+                                 file_size   = excluded.file_size,
+                                 inode       = excluded.inode,
+                                 mod_time    = excluded.mod_time,
+                                 updated_on  = excluded.updated_on,
+                                 checksum    = excluded.checksum
+`
+
+type LogOffsetSaveParams struct {
+	Path       string    `json:"path"`
+	FileOffset int64     `json:"file_offset"`
+	FileSize   int64     `json:"file_size"`
+	Inode      int64     `json:"inode"`
+	ModTime    time.Time `json:"mod_time"`
+	UpdatedOn  time.Time `json:"updated_on"`
+	Checksum   int64     `json:"checksum"`
+}
+
+func (q *Queries) LogOffsetSave(ctx context.Context, arg LogOffsetSaveParams) error {
+	_, err := q.exec(ctx, q.logOffsetSaveStmt, logOffsetSave,
+		arg.Path,
+		arg.FileOffset,
+		arg.FileSize,
+		arg.Inode,
+		arg.ModTime,
+		arg.UpdatedOn,
+		arg.Checksum,
+	)
+	return err
+}
+
 const messageSave = `-- name: MessageSave :exec
-INSERT INTO player_messages (steam_id, message, team, dead, created_on)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO player_messages (steam_id, message, team, dead, created_on, source)
+VALUES (?, ?, ?, ?, ?, ?)
 `
 
 type MessageSaveParams struct {
@@ -472,6 +1125,7 @@ type MessageSaveParams struct {
 	Team      bool      `json:"team"`
 	Dead      bool      `json:"dead"`
 	CreatedOn time.Time `json:"created_on"`
+	Source    string    `json:"source"`
 }
 
 func (q *Queries) MessageSave(ctx context.Context, arg MessageSaveParams) error {
@@ -481,12 +1135,13 @@ func (q *Queries) MessageSave(ctx context.Context, arg MessageSaveParams) error
 		arg.Team,
 		arg.Dead,
 		arg.CreatedOn,
+		arg.Source,
 	)
 	return err
 }
 
 const messages = `-- name: Messages :many
-SELECT message_id, steam_id, message, team, dead, created_on
+SELECT message_id, steam_id, message, team, dead, created_on, source
 FROM player_messages
 WHERE steam_id = ?1
 `
@@ -507,6 +1162,7 @@ func (q *Queries) Messages(ctx context.Context, steamID int64) ([]PlayerMessage,
 			&i.Team,
 			&i.Dead,
 			&i.CreatedOn,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -666,6 +1322,24 @@ func (q *Queries) PlayerInsert(ctx context.Context, arg PlayerInsertParams) (Pla
 	return i, err
 }
 
+const playerKillsAdd = `-- name: PlayerKillsAdd :exec
+UPDATE player
+SET kills_on  = kills_on + ?1,
+    deaths_by = deaths_by + ?2
+WHERE steam_id = ?3
+`
+
+type PlayerKillsAddParams struct {
+	KillsOn  int64 `json:"kills_on"`
+	DeathsBy int64 `json:"deaths_by"`
+	SteamID  int64 `json:"steam_id"`
+}
+
+func (q *Queries) PlayerKillsAdd(ctx context.Context, arg PlayerKillsAddParams) error {
+	_, err := q.exec(ctx, q.playerKillsAddStmt, playerKillsAdd, arg.KillsOn, arg.DeathsBy, arg.SteamID)
+	return err
+}
+
 const playerSearch = `-- name: PlayerSearch :many
 SELECT p.steam_id,
        p.visibility,
@@ -760,6 +1434,55 @@ func (q *Queries) PlayerSearch(ctx context.Context, arg PlayerSearchParams) ([]P
 	return items, nil
 }
 
+const playerSessionKills = `-- name: PlayerSessionKills :many
+SELECT s.session_id, s.server_name, s.map_name, s.start_time, sp.kills_on, sp.deaths_by
+FROM session_players sp
+         INNER JOIN sessions s ON s.session_id = sp.session_id
+WHERE sp.steam_id = ?1
+  AND (sp.kills_on > 0 OR sp.deaths_by > 0)
+ORDER BY s.start_time DESC
+LIMIT 100
+`
+
+type PlayerSessionKillsRow struct {
+	SessionID  int64     `json:"session_id"`
+	ServerName string    `json:"server_name"`
+	MapName    string    `json:"map_name"`
+	StartTime  time.Time `json:"start_time"`
+	KillsOn    int64     `json:"kills_on"`
+	DeathsBy   int64     `json:"deaths_by"`
+}
+
+func (q *Queries) PlayerSessionKills(ctx context.Context, steamID int64) ([]PlayerSessionKillsRow, error) {
+	rows, err := q.query(ctx, q.playerSessionKillsStmt, playerSessionKills, steamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlayerSessionKillsRow
+	for rows.Next() {
+		var i PlayerSessionKillsRow
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.ServerName,
+			&i.MapName,
+			&i.StartTime,
+			&i.KillsOn,
+			&i.DeathsBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const playerUpdate = `-- name: PlayerUpdate :exec
 UPDATE player
 SET visibility         = ?1,
@@ -824,6 +1547,319 @@ func (q *Queries) PlayerUpdate(ctx context.Context, arg PlayerUpdateParams) erro
 	return err
 }
 
+const session = `-- name: Session :one
+SELECT session_id, server_name, address, map_name, tags, start_time, end_time
+FROM sessions
+WHERE session_id = ?1
+`
+
+func (q *Queries) Session(ctx context.Context, sessionID int64) (Session, error) {
+	row := q.queryRow(ctx, q.sessionStmt, session, sessionID)
+	var i Session
+	err := row.Scan(
+		&i.SessionID,
+		&i.ServerName,
+		&i.Address,
+		&i.MapName,
+		&i.Tags,
+		&i.StartTime,
+		&i.EndTime,
+	)
+	return i, err
+}
+
+const sessionCoPlayers = `-- name: SessionCoPlayers :many
+SELECT b.steam_id, count(*) AS sessions
+FROM session_players a
+         INNER JOIN session_players b ON b.session_id = a.session_id AND b.steam_id != a.steam_id
+WHERE a.steam_id = ?1
+  AND a.session_id != ?2
+GROUP BY b.steam_id
+HAVING count(*) >= ?3
+`
+
+type SessionCoPlayersParams struct {
+	SteamID     int64 `json:"steam_id"`
+	SessionID   int64 `json:"session_id"`
+	MinSessions int64 `json:"min_sessions"`
+}
+
+type SessionCoPlayersRow struct {
+	SteamID  int64 `json:"steam_id"`
+	Sessions int64 `json:"sessions"`
+}
+
+func (q *Queries) SessionCoPlayers(ctx context.Context, arg SessionCoPlayersParams) ([]SessionCoPlayersRow, error) {
+	rows, err := q.query(ctx, q.sessionCoPlayersStmt, sessionCoPlayers, arg.SteamID, arg.SessionID, arg.MinSessions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SessionCoPlayersRow
+	for rows.Next() {
+		var i SessionCoPlayersRow
+		if err := rows.Scan(&i.SteamID, &i.Sessions); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sessionEnd = `-- name: SessionEnd :exec
+UPDATE sessions
+SET end_time = ?1
+WHERE session_id = ?2
+`
+
+type SessionEndParams struct {
+	EndTime   sql.NullTime `json:"end_time"`
+	SessionID int64        `json:"session_id"`
+}
+
+func (q *Queries) SessionEnd(ctx context.Context, arg SessionEndParams) error {
+	_, err := q.exec(ctx, q.sessionEndStmt, sessionEnd, arg.EndTime, arg.SessionID)
+	return err
+}
+
+const sessionInsert = `-- name: SessionInsert :one
+INSERT INTO sessions (server_name, address, map_name, tags, start_time)
+VALUES (?, ?, ?, ?, ?)
+RETURNING session_id, server_name, address, map_name, tags, start_time, end_time
+`
+
+type SessionInsertParams struct {
+	ServerName string    `json:"server_name"`
+	Address    string    `json:"address"`
+	MapName    string    `json:"map_name"`
+	Tags       string    `json:"tags"`
+	StartTime  time.Time `json:"start_time"`
+}
+
+func (q *Queries) SessionInsert(ctx context.Context, arg SessionInsertParams) (Session, error) {
+	row := q.queryRow(ctx, q.sessionInsertStmt, sessionInsert,
+		arg.ServerName,
+		arg.Address,
+		arg.MapName,
+		arg.Tags,
+		arg.StartTime,
+	)
+	var i Session
+	err := row.Scan(
+		&i.SessionID,
+		&i.ServerName,
+		&i.Address,
+		&i.MapName,
+		&i.Tags,
+		&i.StartTime,
+		&i.EndTime,
+	)
+	return i, err
+}
+
+const sessionPlayerKillsAdd = `-- name: SessionPlayerKillsAdd :exec
+INSERT INTO session_players (session_id, steam_id, kills_on, deaths_by, updated_on)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (session_id, steam_id) DO UPDATE SET kills_on   = session_players.kills_on + excluded.kills_on,
+                                                 deaths_by  = session_players.deaths_by + excluded.deaths_by,
+                                                 updated_on = excluded.updated_on
+`
+
+type SessionPlayerKillsAddParams struct {
+	SessionID int64     `json:"session_id"`
+	SteamID   int64     `json:"steam_id"`
+	KillsOn   int64     `json:"kills_on"`
+	DeathsBy  int64     `json:"deaths_by"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+func (q *Queries) SessionPlayerKillsAdd(ctx context.Context, arg SessionPlayerKillsAddParams) error {
+	_, err := q.exec(ctx, q.sessionPlayerKillsAddStmt, sessionPlayerKillsAdd,
+		arg.SessionID,
+		arg.SteamID,
+		arg.KillsOn,
+		arg.DeathsBy,
+		arg.UpdatedOn,
+	)
+	return err
+}
+
+const sessionPlayerSave = `-- name: SessionPlayerSave :exec
+INSERT INTO session_players (session_id, steam_id, name, team, score, updated_on)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (session_id, steam_id) DO UPDATE SET name       = excluded.name,
+                                                 team       = excluded.team,
+                                                 score      = excluded.score,
+                                                 updated_on = excluded.updated_on
+`
+
+type SessionPlayerSaveParams struct {
+	SessionID int64     `json:"session_id"`
+	SteamID   int64     `json:"steam_id"`
+	Name      string    `json:"name"`
+	Team      int64     `json:"team"`
+	Score     int64     `json:"score"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+func (q *Queries) SessionPlayerSave(ctx context.Context, arg SessionPlayerSaveParams) error {
+	_, err := q.exec(ctx, q.sessionPlayerSaveStmt, sessionPlayerSave,
+		arg.SessionID,
+		arg.SteamID,
+		arg.Name,
+		arg.Team,
+		arg.Score,
+		arg.UpdatedOn,
+	)
+	return err
+}
+
+const sessionPlayers = `-- name: SessionPlayers :many
+SELECT session_id, steam_id, name, team, score, updated_on, kills_on, deaths_by
+FROM session_players
+WHERE session_id = ?1
+ORDER BY team, score DESC
+`
+
+func (q *Queries) SessionPlayers(ctx context.Context, sessionID int64) ([]SessionPlayer, error) {
+	rows, err := q.query(ctx, q.sessionPlayersStmt, sessionPlayers, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SessionPlayer
+	for rows.Next() {
+		var i SessionPlayer
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.SteamID,
+			&i.Name,
+			&i.Team,
+			&i.Score,
+			&i.UpdatedOn,
+			&i.KillsOn,
+			&i.DeathsBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sessionUpdate = `-- name: SessionUpdate :exec
+UPDATE sessions
+SET server_name = ?1,
+    address     = ?2,
+    map_name    = ?3,
+    tags        = ?4
+WHERE session_id = ?5
+`
+
+type SessionUpdateParams struct {
+	ServerName string `json:"server_name"`
+	Address    string `json:"address"`
+	MapName    string `json:"map_name"`
+	Tags       string `json:"tags"`
+	SessionID  int64  `json:"session_id"`
+}
+
+func (q *Queries) SessionUpdate(ctx context.Context, arg SessionUpdateParams) error {
+	_, err := q.exec(ctx, q.sessionUpdateStmt, sessionUpdate,
+		arg.ServerName,
+		arg.Address,
+		arg.MapName,
+		arg.Tags,
+		arg.SessionID,
+	)
+	return err
+}
+
+const sessions = `-- name: Sessions :many
+SELECT s.session_id,
+       s.server_name,
+       s.address,
+       s.map_name,
+       s.tags,
+       s.start_time,
+       s.end_time,
+       (SELECT count(*) FROM session_players sp WHERE sp.session_id = s.session_id) AS player_count
+FROM sessions s
+WHERE (?1 = 0 OR EXISTS (SELECT 1
+                                FROM session_players sp
+                                WHERE sp.session_id = s.session_id
+                                  AND sp.steam_id = ?1))
+ORDER BY s.start_time DESC
+LIMIT 100
+`
+
+type SessionsRow struct {
+	SessionID   int64        `json:"session_id"`
+	ServerName  string       `json:"server_name"`
+	Address     string       `json:"address"`
+	MapName     string       `json:"map_name"`
+	Tags        string       `json:"tags"`
+	StartTime   time.Time    `json:"start_time"`
+	EndTime     sql.NullTime `json:"end_time"`
+	PlayerCount int64        `json:"player_count"`
+}
+
+func (q *Queries) Sessions(ctx context.Context, steamID interface{}) ([]SessionsRow, error) {
+	rows, err := q.query(ctx, q.sessionsStmt, sessions, steamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SessionsRow
+	for rows.Next() {
+		var i SessionsRow
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.ServerName,
+			&i.Address,
+			&i.MapName,
+			&i.Tags,
+			&i.StartTime,
+			&i.EndTime,
+			&i.PlayerCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sessionsCloseOpen = `-- name: SessionsCloseOpen :exec
+UPDATE sessions
+SET end_time = coalesce((SELECT max(sp.updated_on) FROM session_players sp WHERE sp.session_id = sessions.session_id),
+                        start_time)
+WHERE end_time IS NULL
+`
+
+func (q *Queries) SessionsCloseOpen(ctx context.Context) error {
+	_, err := q.exec(ctx, q.sessionsCloseOpenStmt, sessionsCloseOpen)
+	return err
+}
+
 const sourcebans = `-- name: Sourcebans :many
 SELECT sourcebans_id,
        steam_id,
@@ -921,29 +1957,29 @@ func (q *Queries) SourcebansInsert(ctx context.Context, arg SourcebansInsertPara
 }
 
 const userNameSave = `-- name: UserNameSave :exec
-INSERT INTO player_names (name_id, steam_id, name, created_on)
+INSERT INTO player_names (steam_id, name, created_on, source)
 VALUES (?, ?, ?, ?)
 `
 
 type UserNameSaveParams struct {
-	NameID    int64     `json:"name_id"`
 	SteamID   int64     `json:"steam_id"`
 	Name      string    `json:"name"`
 	CreatedOn time.Time `json:"created_on"`
+	Source    string    `json:"source"`
 }
 
 func (q *Queries) UserNameSave(ctx context.Context, arg UserNameSaveParams) error {
 	_, err := q.exec(ctx, q.userNameSaveStmt, userNameSave,
-		arg.NameID,
 		arg.SteamID,
 		arg.Name,
 		arg.CreatedOn,
+		arg.Source,
 	)
 	return err
 }
 
 const userNames = `-- name: UserNames :many
-SELECT name_id, steam_id, name, created_on
+SELECT name_id, steam_id, name, created_on, source
 FROM player_names
 WHERE steam_id = ?1
 `
@@ -962,6 +1998,7 @@ func (q *Queries) UserNames(ctx context.Context, steamID int64) ([]PlayerName, e
 			&i.SteamID,
 			&i.Name,
 			&i.CreatedOn,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
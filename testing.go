@@ -59,6 +59,8 @@ func CreateTestPlayers(playerState *playerStates, fn mkPlayerFunc, count int) {
 		player.Ping = rand.Intn(150)
 		player.Kills = rand.Intn(50)
 		player.Deaths = rand.Intn(300)
+		player.TimesSeen = int64(rand.Intn(5))
+		player.TimeTogether = int64(rand.Intn(20000))
 
 		idIdx++
 
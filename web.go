@@ -73,12 +73,23 @@ func responseOK(w http.ResponseWriter, status int, data any) {
 
 // createHandlers configures the routes. If the `release` tag is enabled, serves files from the embedded assets
 // in the binary.
-func createHandlers(ctx context.Context, store store.Querier, state *gameState, process *processState, cfgMgr configManager, re *rules.Engine, rcon rconConnection) (*http.ServeMux, error) {
+func createHandlers(ctx context.Context, store store.Querier, state *gameState, process *processState,
+	cfgMgr configManager, re *rules.Engine, queue *kickQueue, broadcaster *eventBroadcaster,
+	scheduler *rconScheduler,
+) (*http.ServeMux, error) {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /api/state", onGetState(state, process))
+	mux.HandleFunc("GET /api/killfeed", onGetKillFeed(state))
+	mux.HandleFunc("GET /api/consumers", onGetConsumers(broadcaster))
+	mux.HandleFunc("GET /api/rcon/queues", onGetRCONQueues(scheduler))
+	mux.HandleFunc("GET /api/events", onGetEvents(store))
+	mux.HandleFunc("GET /api/sessions", onGetSessions(store))
+	mux.HandleFunc("GET /api/sessions/{session_id}", onGetSession(store))
+	mux.HandleFunc("GET /api/encounters/{steam_id}", onGetEncounter(store))
 	mux.HandleFunc("GET /api/messages/{steam_id}", onGetMessages(store))
 	mux.HandleFunc("GET /api/names/{steam_id}", onGetNames(store))
+	mux.HandleFunc("GET /api/profile/{steam_id}", onGetProfile(store, state))
 	mux.HandleFunc("POST /api/mark/{steam_id}", onMarkPlayerPost(cfgMgr, store, state, re))
 	mux.HandleFunc("DELETE /api/mark/{steam_id}", onDeleteMarkedPlayer(store, state, re))
 	mux.HandleFunc("GET /api/settings", onGetSettings(cfgMgr, re))
@@ -88,7 +99,16 @@ func createHandlers(ctx context.Context, store store.Querier, state *gameState,
 	mux.HandleFunc("POST /api/whitelist/{steam_id}", onUpdateWhitelistPlayer(store, state, true))
 	mux.HandleFunc("DELETE /api/whitelist/{steam_id}", onUpdateWhitelistPlayer(store, state, false))
 	mux.HandleFunc("POST /api/notes/{steam_id}", onPostNotes(store, state))
-	mux.HandleFunc("POST /api/callvote/{steam_id}/{reason}", onCallVote(state, rcon))
+	mux.HandleFunc("POST /api/callvote/{steam_id}/{reason}", onCallVote(state, queue))
+	mux.HandleFunc("GET /api/kickqueue", onGetKickQueue(state, queue))
+	mux.HandleFunc("GET /api/kicks", onGetKickVotes(store))
+	mux.HandleFunc("GET /api/kicks/stats", onGetKickStats(store))
+	mux.HandleFunc("POST /api/kickqueue", onPostKickQueue(state, queue))
+	mux.HandleFunc("DELETE /api/kickqueue/{steam_id}", onDeleteKickQueue(queue))
+	mux.HandleFunc("POST /api/demo", onPostDemoImport(store, re, cfgMgr))
+	mux.HandleFunc("GET /api/announcements", onGetAnnouncements(store))
+	mux.HandleFunc("PUT /api/announcements", onPutAnnouncements(store))
+	mux.HandleFunc("POST /api/announcements/preview", onPostAnnouncementPreview(state))
 
 	settings, errSettings := cfgMgr.settings(ctx)
 	if errSettings != nil {
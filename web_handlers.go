@@ -1,13 +1,17 @@
 package main
 
 import (
+	"database/sql"
 	"errors"
-	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/leighmacdonald/bd/rules"
 	"github.com/leighmacdonald/bd/store"
+	"github.com/leighmacdonald/steamid/v4/steamid"
 )
 
 func onGetMessages(store store.Querier) http.HandlerFunc {
@@ -29,6 +33,294 @@ func onGetMessages(store store.Querier) http.HandlerFunc {
 	}
 }
 
+// onGetEvents queries the event journal. All query parameters are optional: `start` and `end` are RFC3339
+// timestamps, `type` is an event name such as `kill` or `msg` and `steam_id` matches either the player or the
+// victim of the event.
+func onGetEvents(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			query  = r.URL.Query()
+			params = store.EventsParams{EndTime: time.Now(), EventType: "", SteamID: 0}
+		)
+
+		if start := query.Get("start"); start != "" {
+			startTime, errStart := time.Parse(time.RFC3339, start)
+			if errStart != nil {
+				responseErr(w, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			params.StartTime = startTime
+		}
+
+		if end := query.Get("end"); end != "" {
+			endTime, errEnd := time.Parse(time.RFC3339, end)
+			if errEnd != nil {
+				responseErr(w, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			params.EndTime = endTime
+		}
+
+		if typeName := query.Get("type"); typeName != "" {
+			eventType, found := parseEventType(typeName)
+			if !found {
+				responseErr(w, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			params.EventType = eventType.String()
+		}
+
+		if sidValue := query.Get("steam_id"); sidValue != "" {
+			steamID := steamid.New(sidValue)
+			if !steamID.Valid() {
+				responseErr(w, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			params.SteamID = steamID.Int64()
+		}
+
+		rows, errEvents := db.Events(r.Context(), params)
+		if errEvents != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch events", errAttr(errEvents))
+
+			return
+		}
+
+		events := make([]JournalEvent, len(rows))
+		for index, row := range rows {
+			events[index] = newJournalEvent(row)
+		}
+
+		responseOK(w, http.StatusOK, events)
+	}
+}
+
+// onGetSessions lists the most recent game sessions. The optional `steam_id` query parameter limits the results to
+// sessions the player was present in.
+func onGetSessions(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var steamID int64
+
+		if sidValue := r.URL.Query().Get("steam_id"); sidValue != "" {
+			sid := steamid.New(sidValue)
+			if !sid.Valid() {
+				responseErr(w, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			steamID = sid.Int64()
+		}
+
+		rows, errSessions := db.Sessions(r.Context(), steamID)
+		if errSessions != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch sessions", errAttr(errSessions))
+
+			return
+		}
+
+		sessions := make([]GameSession, len(rows))
+		for index, row := range rows {
+			sessions[index] = newGameSession(row.SessionID, row.ServerName, row.Address, row.MapName, row.Tags,
+				row.StartTime, row.EndTime)
+			sessions[index].PlayerCount = int(row.PlayerCount)
+		}
+
+		responseOK(w, http.StatusOK, sessions)
+	}
+}
+
+// onGetKickVotes returns the recorded kick votes, optionally only those against a single player. The result of a
+// vote is never shown in the console, the target_left and target_stayed outcomes are inferred from whether the
+// target left while the vote ran.
+func onGetKickVotes(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var steamID int64
+
+		if sidValue := r.URL.Query().Get("steam_id"); sidValue != "" {
+			sid := steamid.New(sidValue)
+			if !sid.Valid() {
+				responseErr(w, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			steamID = sid.Int64()
+		}
+
+		rows, errVotes := db.KickVotes(r.Context(), steamID)
+		if errVotes != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch kick votes", errAttr(errVotes))
+
+			return
+		}
+
+		votes := make([]KickVote, len(rows))
+		for index, row := range rows {
+			votes[index] = newKickVote(row)
+		}
+
+		responseOK(w, http.StatusOK, votes)
+	}
+}
+
+// onGetKickStats returns the kick vote outcomes per attribute, list and server. Like onGetKickVotes, the success
+// rates are based on the inferred outcomes, which the response notes in outcome_note.
+func onGetKickStats(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagRows, errTags := db.KickVoteStatsByTag(r.Context())
+		if errTags != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch kick vote tag stats", errAttr(errTags))
+
+			return
+		}
+
+		serverRows, errServers := db.KickVoteStatsByServer(r.Context())
+		if errServers != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch kick vote server stats", errAttr(errServers))
+
+			return
+		}
+
+		stats := KickStats{
+			Attributes:  []KickStat{},
+			Lists:       []KickStat{},
+			Servers:     []KickStat{},
+			OutcomeNote: kickOutcomeNote,
+		}
+
+		for _, row := range tagRows {
+			stat := newKickStat(row.Value, "", row.Total, row.TargetLeft, row.TargetStayed, row.Cancelled,
+				row.Rejected)
+
+			switch row.Kind {
+			case kickTagAttribute:
+				stats.Attributes = append(stats.Attributes, stat)
+			case kickTagList:
+				stats.Lists = append(stats.Lists, stat)
+			}
+		}
+
+		for _, row := range serverRows {
+			stats.Servers = append(stats.Servers, newKickStat(row.ServerName, row.Address, row.Total,
+				row.TargetLeft, row.TargetStayed, row.Cancelled, row.Rejected))
+		}
+
+		responseOK(w, http.StatusOK, stats)
+	}
+}
+
+// onGetSession returns a single game session along with every player that was present.
+func onGetSession(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID, errID := strconv.ParseInt(r.PathValue("session_id"), 10, 64)
+		if errID != nil {
+			responseErr(w, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		row, errSession := db.Session(r.Context(), sessionID)
+		if errSession != nil {
+			if errors.Is(errSession, sql.ErrNoRows) {
+				responseErr(w, http.StatusNotFound, nil)
+
+				return
+			}
+
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch session", errAttr(errSession))
+
+			return
+		}
+
+		players, errPlayers := db.SessionPlayers(r.Context(), sessionID)
+		if errPlayers != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch session players", errAttr(errPlayers))
+
+			return
+		}
+
+		session := newGameSession(row.SessionID, row.ServerName, row.Address, row.MapName, row.Tags,
+			row.StartTime, row.EndTime)
+		session.PlayerCount = len(players)
+
+		for _, player := range players {
+			session.Players = append(session.Players, SessionPlayer{
+				SteamID:   steamid.New(player.SteamID),
+				Name:      player.Name,
+				Team:      Team(player.Team),
+				Score:     int(player.Score),
+				KillsOn:   player.KillsOn,
+				DeathsBy:  player.DeathsBy,
+				UpdatedOn: player.UpdatedOn,
+			})
+		}
+
+		responseOK(w, http.StatusOK, session)
+	}
+}
+
+// onGetEncounter returns the encounter history with a player, including the servers we played together on.
+func onGetEncounter(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sid, sidOk := steamIDParam(w, r)
+		if !sidOk {
+			return
+		}
+
+		encounter, errEncounter := db.Encounter(r.Context(), sid.Int64())
+		if errEncounter != nil {
+			if errors.Is(errEncounter, sql.ErrNoRows) {
+				responseErr(w, http.StatusNotFound, nil)
+
+				return
+			}
+
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch encounter", errAttr(errEncounter))
+
+			return
+		}
+
+		servers, errServers := db.EncounterServers(r.Context(), sid.Int64())
+		if errServers != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch encounter servers", errAttr(errServers))
+
+			return
+		}
+
+		if servers == nil {
+			servers = []store.EncounterServersRow{}
+		}
+
+		responseOK(w, http.StatusOK, Encounter{
+			SteamID:      sid,
+			FirstSeen:    encounter.FirstSeen,
+			LastSeen:     encounter.LastSeen,
+			PreviousSeen: encounter.PreviousSeen,
+			TotalTime:    encounter.TotalTime,
+			Sessions:     encounter.Sessions,
+			Servers:      servers,
+		})
+	}
+}
+
 func onGetQuitGame(process *processState) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !process.gameProcessActive.Load() {
@@ -75,11 +367,61 @@ func onGetNames(store store.Querier) http.HandlerFunc {
 	}
 }
 
+// PlayerProfile is the stored player data along with the kills and deaths between us in past sessions.
+type PlayerProfile struct {
+	Player   PlayerState                   `json:"player"`
+	Sessions []store.PlayerSessionKillsRow `json:"sessions"`
+}
+
+// onGetProfile returns a players profile. Players in the current game are returned with their live state.
+func onGetProfile(db store.Querier, state *gameState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sid, sidOk := steamIDParam(w, r)
+		if !sidOk {
+			return
+		}
+
+		player, errPlayer := state.players.bySteamID(sid)
+		if errPlayer != nil {
+			row, errRow := db.Player(r.Context(), sid.Int64())
+			if errRow != nil {
+				if errors.Is(errRow, sql.ErrNoRows) {
+					responseErr(w, http.StatusNotFound, nil)
+
+					return
+				}
+
+				responseErr(w, http.StatusInternalServerError, nil)
+				slog.Error("Failed to fetch player", errAttr(errRow))
+
+				return
+			}
+
+			player = playerRowToPlayerState(row)
+		}
+
+		sessions, errSessions := db.PlayerSessionKills(r.Context(), sid.Int64())
+		if errSessions != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch player session kills", errAttr(errSessions))
+
+			return
+		}
+
+		if sessions == nil {
+			sessions = []store.PlayerSessionKillsRow{}
+		}
+
+		responseOK(w, http.StatusOK, PlayerProfile{Player: player, Sessions: sessions})
+	}
+}
+
 type CurrentState struct {
 	Tags        []string      `json:"tags"`
 	GameRunning bool          `json:"game_running"`
 	Server      serverState   `json:"server"`
 	Players     []PlayerState `json:"players"`
+	Parties     []Party       `json:"parties"`
 }
 
 func onGetState(state *gameState, process *processState) http.HandlerFunc {
@@ -89,17 +431,51 @@ func onGetState(state *gameState, process *processState) http.HandlerFunc {
 			players = []PlayerState{}
 		}
 
+		parties := state.Parties()
+		if parties == nil {
+			parties = []Party{}
+		}
+
 		server := state.CurrentServerState()
 
 		responseOK(w, http.StatusOK, CurrentState{
 			Tags:        []string{},
 			Server:      server,
 			Players:     players,
+			Parties:     parties,
 			GameRunning: process.gameProcessActive.Load(),
 		})
 	}
 }
 
+func onGetKillFeed(state *gameState) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		feed := state.KillFeed()
+		if feed == nil {
+			feed = []KillFeedEntry{}
+		}
+
+		responseOK(w, http.StatusOK, feed)
+	}
+}
+
+func onGetConsumers(broadcaster *eventBroadcaster) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		stats := broadcaster.consumerStats()
+		if stats == nil {
+			stats = []ConsumerStats{}
+		}
+
+		responseOK(w, http.StatusOK, stats)
+	}
+}
+
+func onGetRCONQueues(scheduler *rconScheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		responseOK(w, http.StatusOK, scheduler.queueStats())
+	}
+}
+
 func onGGetLaunchGame(process *processState, settingsMgr configManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if process.gameProcessActive.Load() {
@@ -176,41 +552,128 @@ func onPutSettings(settings configManager) http.HandlerFunc {
 	}
 }
 
-func onCallVote(state *gameState, connection rconConnection) http.HandlerFunc {
+// onCallVote queues a kick against the player ahead of any others. The vote is called by the kicker once the
+// vote cooldown allows it.
+func onCallVote(state *gameState, queue *kickQueue) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sid, sidOk := steamIDParam(w, r)
 		if !sidOk {
 			return
 		}
 
-		player, errPlayer := state.players.bySteamID(sid)
-		if errPlayer != nil {
+		if _, errPlayer := state.players.bySteamID(sid); errPlayer != nil {
 			responseErr(w, http.StatusNotFound, nil)
 			slog.Error("Failed to get player state", errAttr(errPlayer), slog.String("steam_id", sid.String()))
 
 			return
 		}
 
-		if player.UserID <= 0 {
+		reason := KickReason(r.PathValue("reason"))
+		if !reason.valid() {
+			responseErr(w, http.StatusBadRequest, nil)
+			slog.Error("Invalid kick reason", slog.String("reason", string(reason)))
+
+			return
+		}
+
+		queue.enqueue(sid, reason, 0)
+
+		responseOK(w, http.StatusNoContent, nil)
+	}
+}
+
+func onGetKickQueue(state *gameState, queue *kickQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		entries := []KickQueueEntry{}
+
+		for _, request := range queue.entries() {
+			entry := KickQueueEntry{
+				SteamID:  request.steamID,
+				Reason:   request.reason,
+				Attempts: request.attempts,
+				Outcome:  request.outcome,
+			}
+
+			if player, errPlayer := state.players.bySteamID(request.steamID); errPlayer == nil {
+				entry.Name = player.Personaname
+			}
+
+			if !request.lastAttempt.IsZero() {
+				lastAttempt := request.lastAttempt
+				entry.LastAttempt = &lastAttempt
+			}
+
+			entries = append(entries, entry)
+		}
+
+		responseOK(w, http.StatusOK, entries)
+	}
+}
+
+// PostKickQueueOpts adds a player to the kick queue. When the player is already queued, they are moved to the
+// position given and their reason is updated. Players are added to the end of the queue when position is not set.
+type PostKickQueueOpts struct {
+	SteamID  string     `json:"steam_id"`
+	Reason   KickReason `json:"reason"`
+	Position *int       `json:"position"`
+}
+
+func onPostKickQueue(state *gameState, queue *kickQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts PostKickQueueOpts
+		if !bind(w, r, &opts) {
+			return
+		}
+
+		sid := steamid.New(opts.SteamID)
+		if !sid.Valid() {
+			responseErr(w, http.StatusBadRequest, nil)
+			slog.Error("Failed to parse steam id", slog.String("steam_id", opts.SteamID))
+
+			return
+		}
+
+		if opts.Reason == "" {
+			opts.Reason = KickReasonCheating
+		}
+
+		if !opts.Reason.valid() {
+			responseErr(w, http.StatusBadRequest, nil)
+			slog.Error("Invalid kick reason", slog.String("reason", string(opts.Reason)))
+
+			return
+		}
+
+		if _, errPlayer := state.players.bySteamID(sid); errPlayer != nil {
 			responseErr(w, http.StatusNotFound, nil)
-			slog.Error("Failed to get player user id", slog.String("steam_id", sid.String()))
+			slog.Error("Failed to get player state", errAttr(errPlayer), slog.String("steam_id", sid.String()))
 
 			return
 		}
 
-		reason := KickReason(r.PathValue("reason"))
+		position := -1
+		if opts.Position != nil {
+			position = *opts.Position
+		}
 
-		cmd := fmt.Sprintf("callvote kick \"%d %s\"", player.UserID, reason)
+		queue.enqueue(sid, opts.Reason, position)
 
-		resp, errCallVote := connection.exec(r.Context(), cmd, false)
-		if errCallVote != nil {
-			responseErr(w, http.StatusInternalServerError, nil)
-			slog.Error("Failed to call vote", slog.String("steam_id", sid.String()), errAttr(errCallVote))
+		responseOK(w, http.StatusNoContent, nil)
+	}
+}
 
+func onDeleteKickQueue(queue *kickQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sid, sidOk := steamIDParam(w, r)
+		if !sidOk {
 			return
 		}
 
-		slog.Debug(resp, slog.String("cmd", cmd))
+		if !queue.remove(sid) {
+			responseErr(w, http.StatusNotFound, nil)
+
+			return
+		}
 
 		responseOK(w, http.StatusNoContent, nil)
 	}
@@ -256,6 +719,47 @@ func onPostNotes(db store.Querier, state *gameState) http.HandlerFunc {
 	}
 }
 
+// PostDemoImportOpts names the demo to import, relative to the demos directory within the tf directory.
+type PostDemoImportOpts struct {
+	Name string `json:"name"`
+}
+
+func onPostDemoImport(db store.Querier, re *rules.Engine, settingsMgr configManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts PostDemoImportOpts
+		if !bind(w, r, &opts) {
+			return
+		}
+
+		settings, errSettings := settingsMgr.settings(r.Context())
+		if errSettings != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to read settings", errAttr(errSettings))
+
+			return
+		}
+
+		result, errImport := importDemo(r.Context(), db, re, settings.Tf2Dir, opts.Name)
+		if errImport != nil {
+			switch {
+			case errors.Is(errImport, errDemoExtension), errors.Is(errImport, errDemoPath),
+				errors.Is(errImport, errDemoParse):
+				responseErr(w, http.StatusBadRequest, nil)
+			case errors.Is(errImport, errDemoOpen):
+				responseErr(w, http.StatusNotFound, nil)
+			default:
+				responseErr(w, http.StatusInternalServerError, nil)
+			}
+
+			slog.Error("Failed to import demo", errAttr(errImport), slog.String("name", opts.Name))
+
+			return
+		}
+
+		responseOK(w, http.StatusOK, result)
+	}
+}
+
 type PostMarkPlayerOpts struct {
 	Attrs []string `json:"attrs"`
 }
@@ -350,3 +854,107 @@ func onUpdateWhitelistPlayer(db store.Querier, state *gameState, enable bool) ht
 		responseOK(w, http.StatusNoContent, nil)
 	}
 }
+
+func onGetAnnouncements(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, errRows := db.Announcements(r.Context())
+		if errRows != nil {
+			responseErr(w, http.StatusInternalServerError, nil)
+			slog.Error("Failed to fetch announcements", errAttr(errRows))
+
+			return
+		}
+
+		announcements := make([]Announcement, len(rows))
+		for index, row := range rows {
+			announcements[index] = newAnnouncement(row)
+		}
+
+		responseOK(w, http.StatusOK, announcements)
+	}
+}
+
+// onPutAnnouncements updates the announcements for each severity given. The severities themselves are fixed.
+func onPutAnnouncements(db store.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var announcements []Announcement
+		if !bind(w, r, &announcements) {
+			return
+		}
+
+		for _, announcement := range announcements {
+			switch announcement.Destination {
+			case ChatDestAll, ChatDestTeam, ChatDestParty:
+			default:
+				responseErr(w, http.StatusBadRequest, nil)
+				slog.Error("Invalid announcement destination", slog.String("destination", string(announcement.Destination)))
+
+				return
+			}
+
+			if _, errParse := parseAnnouncement(announcement.Template); errParse != nil {
+				responseErr(w, http.StatusBadRequest, errParse.Error())
+
+				return
+			}
+		}
+
+		for _, announcement := range announcements {
+			if errUpdate := db.AnnouncementUpdate(r.Context(), store.AnnouncementUpdateParams{
+				Attributes:  strings.Join(announcement.Attributes, ","),
+				Destination: string(announcement.Destination),
+				Template:    announcement.Template,
+				Enabled:     announcement.Enabled,
+				Severity:    announcement.Severity,
+			}); errUpdate != nil {
+				responseErr(w, http.StatusInternalServerError, nil)
+				slog.Error("Failed to save announcement", errAttr(errUpdate))
+
+				return
+			}
+		}
+
+		responseOK(w, http.StatusNoContent, nil)
+	}
+}
+
+type PostAnnouncementPreviewOpts struct {
+	Template string `json:"template"`
+	SteamID  string `json:"steam_id"`
+}
+
+type AnnouncementPreview struct {
+	Message string `json:"message"`
+}
+
+// onPostAnnouncementPreview renders a template against a current player, using their first match. Players
+// without any matches are rendered with an example match instead.
+func onPostAnnouncementPreview(state *gameState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts PostAnnouncementPreviewOpts
+		if !bind(w, r, &opts) {
+			return
+		}
+
+		player, errPlayer := state.players.bySteamID(steamid.New(opts.SteamID))
+		if errPlayer != nil {
+			responseErr(w, http.StatusNotFound, nil)
+
+			return
+		}
+
+		match := rules.MatchResult{Origin: "example", Attributes: []string{"cheater"}}
+		if len(player.Matches) > 0 {
+			match = player.Matches[0]
+		}
+
+		msg, errRender := renderAnnouncement(opts.Template, newAnnounceData(player, match))
+		if errRender != nil {
+			responseErr(w, http.StatusBadRequest, errRender.Error())
+
+			return
+		}
+
+		responseOK(w, http.StatusOK, AnnouncementPreview{Message: msg})
+	}
+}